@@ -0,0 +1,83 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    
+    "github.com/dgraph-io/badger/v4"
+)
+
+// gcDiscardRatio is the share of a value log file that must be stale before
+// the gc command rewrites it
+const gcDiscardRatio = 0.5
+
+// flattenDatabase compacts every level of the LSM tree into one, dropping
+// deleted and overwritten keys, using workers concurrent compactions
+func flattenDatabase(db *badger.DB, workers int) {
+    if workers < 1 {
+        log.Fatal("Please specify at least one worker using -workers flag")
+    }
+    
+    printDiskSize(db, "Before")
+    if err := db.Flatten(workers); err != nil {
+        log.Fatalf("Error flattening database: %v", err)
+    }
+    printDiskSize(db, "After")
+}
+
+// collectGarbage rewrites value log files until none has enough stale data
+// left to be worth rewriting
+func collectGarbage(db *badger.DB) {
+    printDiskSize(db, "Before")
+    rewritten := 0
+    for {
+        err := db.RunValueLogGC(gcDiscardRatio)
+        if err == badger.ErrNoRewrite {
+            break
+        }
+        if err != nil {
+            log.Fatalf("Error collecting garbage: %v", err)
+        }
+        rewritten++
+    }
+    fmt.Printf("Rewrote %d value log files\n", rewritten)
+    printDiskSize(db, "After")
+}
+
+// printDiskSize prints the size of the database files. db.Size() is only
+// refreshed once a minute, so the files are measured directly.
+func printDiskSize(db *badger.DB, label string) {
+    lsm, err := filesSize(db.Opts().Dir, ".sst")
+    if err != nil {
+        log.Fatalf("Error measuring database: %v", err)
+    }
+    vlog, err := filesSize(db.Opts().ValueDir, ".vlog")
+    if err != nil {
+        log.Fatalf("Error measuring database: %v", err)
+    }
+    
+    fmt.Printf("%s: LSM %d bytes, value log %d bytes\n", label, lsm, vlog)
+}
+
+// filesSize sums the sizes of the files in dir with the given extension
+func filesSize(dir, ext string) (int64, error) {
+    entries, err := os.ReadDir(dir)
+    if err != nil {
+        return 0, err
+    }
+    
+    var total int64
+    for _, entry := range entries {
+        if entry.IsDir() || filepath.Ext(entry.Name()) != ext {
+            continue
+        }
+        info, err := entry.Info()
+        if err != nil {
+            return 0, err
+        }
+        total += info.Size()
+    }
+    return total, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "reflect"
+    "strings"
+    "time"
+)
+
+// The record types mirror those of the multi-table example so -entity can
+// print its values field by field
+
+type userRecord struct {
+    ID        int64     `json:"id"`
+    Name      string    `json:"name"`
+    Email     string    `json:"email"`
+    CompanyID int64     `json:"company_id"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+type companyRecord struct {
+    ID        int64     `json:"id"`
+    Name      string    `json:"name"`
+    Industry  string    `json:"industry"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+type orderRecord struct {
+    ID        int64            `json:"id"`
+    UserID    int64            `json:"user_id"`
+    ProductID int64            `json:"product_id"`
+    Quantity  int              `json:"quantity"`
+    LineItems []lineItemRecord `json:"line_items,omitempty"`
+    Amount    float64          `json:"amount"`
+    Status    string           `json:"status"`
+    CreatedAt time.Time        `json:"created_at"`
+}
+
+type lineItemRecord struct {
+    ProductID int64   `json:"product_id"`
+    Quantity  int     `json:"quantity"`
+    UnitPrice float64 `json:"unit_price"`
+}
+
+type productRecord struct {
+    ID          int64   `json:"id"`
+    Name        string  `json:"name"`
+    Price       float64 `json:"price"`
+    CategoryID  int64   `json:"category_id"`
+    CompanyID   int64   `json:"company_id"`
+    Description string  `json:"description"`
+}
+
+type categoryRecord struct {
+    ID   int64  `json:"id"`
+    Name string `json:"name"`
+}
+
+// entityRecords maps the names accepted by -entity to a constructor of the
+// matching record type
+var entityRecords = map[string]func() interface{}{
+    "user":     func() interface{} { return &userRecord{} },
+    "company":  func() interface{} { return &companyRecord{} },
+    "order":    func() interface{} { return &orderRecord{} },
+    "product":  func() interface{} { return &productRecord{} },
+    "category": func() interface{} { return &categoryRecord{} },
+}
+
+// formatValue returns val decoded as entity and printed one field per line.
+// Unknown entities and values that do not decode, including those with fields
+// the record type lacks, are returned as is.
+func formatValue(val []byte, entity string) string {
+    newRecord, ok := entityRecords[entity]
+    if !ok {
+        return string(val)
+    }
+    
+    record := newRecord()
+    dec := json.NewDecoder(bytes.NewReader(val))
+    dec.DisallowUnknownFields()
+    if err := dec.Decode(record); err != nil {
+        return string(val)
+    }
+    
+    var b strings.Builder
+    writeFields(&b, reflect.ValueOf(record).Elem())
+    return b.String()
+}
+
+// writeFields writes each field of the struct v as "Name: value" on its own
+// line. Slices of structs are listed below their field, one item per line, and
+// left out when empty.
+func writeFields(b *strings.Builder, v reflect.Value) {
+    for i := 0; i < v.NumField(); i++ {
+        name, field := v.Type().Field(i).Name, v.Field(i)
+        if field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct {
+            if field.Len() == 0 {
+                continue
+            }
+            fmt.Fprintf(b, "\n  %s:", name)
+            for j := 0; j < field.Len(); j++ {
+                fmt.Fprintf(b, "\n    - %s", inlineFields(field.Index(j)))
+            }
+            continue
+        }
+        fmt.Fprintf(b, "\n  %s: %s", name, formatField(field))
+    }
+}
+
+// inlineFields formats the fields of the struct v on one line
+func inlineFields(v reflect.Value) string {
+    parts := make([]string, v.NumField())
+    for i := range parts {
+        parts[i] = fmt.Sprintf("%s: %s", v.Type().Field(i).Name, formatField(v.Field(i)))
+    }
+    return strings.Join(parts, ", ")
+}
+
+// formatField formats a single field, times in RFC3339
+func formatField(v reflect.Value) string {
+    if t, ok := v.Interface().(time.Time); ok {
+        return t.Format(time.RFC3339)
+    }
+    return fmt.Sprint(v.Interface())
+}
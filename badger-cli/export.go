@@ -0,0 +1,143 @@
+package main
+
+import (
+    "bytes"
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log"
+    "os"
+    "sort"
+
+    "github.com/dgraph-io/badger/v4"
+)
+
+// exportPrefix writes every value under prefix to out ("" for stdout) in the
+// given format
+func exportPrefix(db *badger.DB, prefix, format, out string) {
+    var w io.Writer = os.Stdout
+    if out != "" {
+        f, err := os.Create(out)
+        if err != nil {
+            log.Fatalf("Failed to create output file: %v", err)
+        }
+        defer f.Close()
+        w = f
+    }
+    
+    var count int
+    var err error
+    switch format {
+    case "csv":
+        count, err = exportCSV(db, prefix, w)
+    case "ndjson":
+        count, err = exportNDJSON(db, prefix, w)
+    default:
+        log.Fatalf("Unknown export format: %s. Use 'csv' or 'ndjson'", format)
+    }
+    if err != nil {
+        log.Fatalf("Error exporting prefix: %v", err)
+    }
+    
+    if out != "" {
+        fmt.Printf("Exported %d records with prefix '%s' to %s\n", count, prefix, out)
+    }
+}
+
+// exportCSV writes the JSON object values under prefix as CSV rows. The header
+// is the sorted union of the fields of all records, so records missing a
+// field get an empty cell. Values that are not JSON objects are skipped.
+func exportCSV(db *badger.DB, prefix string, w io.Writer) (int, error) {
+    count := 0
+    err := db.View(func(txn *badger.Txn) error {
+        // First pass: collect the union of field names
+        fields := make(map[string]bool)
+        err := forEachObject(txn, prefix, false, func(record map[string]json.RawMessage) error {
+            for field := range record {
+                fields[field] = true
+            }
+            return nil
+        })
+        if err != nil {
+            return err
+        }
+        
+        header := make([]string, 0, len(fields))
+        for field := range fields {
+            header = append(header, field)
+        }
+        sort.Strings(header)
+        
+        cw := csv.NewWriter(w)
+        if err := cw.Write(header); err != nil {
+            return err
+        }
+        
+        // Second pass: write one row per record in header order
+        err = forEachObject(txn, prefix, true, func(record map[string]json.RawMessage) error {
+            row := make([]string, len(header))
+            for i, field := range header {
+                if raw, ok := record[field]; ok {
+                    row[i] = csvCell(raw)
+                }
+            }
+            count++
+            return cw.Write(row)
+        })
+        if err != nil {
+            return err
+        }
+        
+        cw.Flush()
+        return cw.Error()
+    })
+    return count, err
+}
+
+// forEachObject calls fn with every value under prefix that decodes as a JSON
+// object, optionally reporting the keys it skips on stderr
+func forEachObject(txn *badger.Txn, prefix string, reportSkipped bool, fn func(map[string]json.RawMessage) error) error {
+    opts := badger.DefaultIteratorOptions
+    opts.Prefix = []byte(prefix)
+    it := txn.NewIterator(opts)
+    defer it.Close()
+    
+    for it.Rewind(); it.Valid(); it.Next() {
+        item := it.Item()
+        var record map[string]json.RawMessage
+        err := item.Value(func(val []byte) error {
+            return json.Unmarshal(val, &record)
+        })
+        if err != nil || record == nil {
+            if reportSkipped {
+                fmt.Fprintf(os.Stderr, "Skipping key %s: value is not a JSON object\n", item.Key())
+            }
+            continue
+        }
+        if err := fn(record); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// csvCell renders a JSON value for a CSV cell: strings unquoted, null empty,
+// and nested objects and arrays as compact JSON
+func csvCell(raw json.RawMessage) string {
+    var s string
+    if err := json.Unmarshal(raw, &s); err == nil {
+        return s
+    }
+    
+    trimmed := bytes.TrimSpace(raw)
+    if bytes.Equal(trimmed, []byte("null")) {
+        return ""
+    }
+    
+    var compact bytes.Buffer
+    if err := json.Compact(&compact, trimmed); err != nil {
+        return string(trimmed)
+    }
+    return compact.String()
+}
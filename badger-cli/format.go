@@ -0,0 +1,46 @@
+package main
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+
+    "github.com/dgraph-io/badger/v4"
+)
+
+// supportedFormatVersion is the on-disk format version written by Badger v4,
+// stored in bytes 6-8 of the MANIFEST file after the "Bdgr" magic
+const supportedFormatVersion = 8
+
+// checkFormatVersion reports a clear error when the database in dir was
+// written in a format this build of Badger cannot open. A directory without
+// a MANIFEST holds no database yet and passes.
+func checkFormatVersion(dir string) error {
+    f, err := os.Open(filepath.Join(dir, badger.ManifestFilename))
+    if errors.Is(err, os.ErrNotExist) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+
+    header := make([]byte, 8)
+    if _, err := io.ReadFull(f, header); err != nil {
+        return fmt.Errorf("%s does not contain a Badger database: unreadable MANIFEST: %w", dir, err)
+    }
+    if string(header[:4]) != "Bdgr" {
+        return fmt.Errorf("%s does not contain a Badger database: bad MANIFEST magic", dir)
+    }
+
+    version := binary.BigEndian.Uint16(header[6:8])
+    if version != supportedFormatVersion {
+        return fmt.Errorf("%s uses Badger format version %d but this tool supports version %d (Badger v4); "+
+            "open it with a Badger release that matches the format, or back it up and restore it into a new directory with that release",
+            dir, version, supportedFormatVersion)
+    }
+    return nil
+}
@@ -1,21 +1,60 @@
 package main
 
 import (
+    "bytes"
+    "encoding/json"
     "flag"
     "fmt"
     "log"
+    "os"
+    "regexp"
     "strings"
-    "github.com/dgraph-io/badger/v3"
+    "github.com/dgraph-io/badger/v4"
 )
 
+// writeCommands lists the commands that need the database opened read-write
+var writeCommands = map[string]bool{
+    "restore": true,
+    "import":  true,
+    "drop":    true,
+    "tail":    true,
+    "flatten": true,
+    "gc":      true,
+}
+
 func main() {
     // Parse command line flags
     dbPath := flag.String("db", "/path/to/db", "path to the BadgerDB database directory")
-    command := flag.String("cmd", "summary", "command to execute: 'summary' or 'view'")
-    prefix := flag.String("prefix", "", "key prefix to view (required for 'view' command)")
+    command := flag.String("cmd", "summary", "command to execute: 'summary', 'sizes', 'view', 'get', 'verify', 'backup', 'restore', 'drop', 'export', 'import', 'tail', 'flatten' or 'gc'")
+    prefix := flag.String("prefix", "", "key prefix to view, drop, export or tail (required for 'view', 'drop' and 'export' commands, comma-separated list for 'view')")
+    out := flag.String("out", "", "file to write (required for 'backup' command, stdout if empty for 'export')")
+    in := flag.String("in", "", "file to read (required for 'restore' and 'import' commands)")
+    since := flag.Uint64("since", 0, "only back up keys newer than this version (for incremental backups)")
+    confirm := flag.Bool("confirm", false, "confirm deleting keys (required for 'drop' command)")
+    format := flag.String("format", "csv", "export format: 'csv' or 'ndjson', import format: 'ndjson'")
+    key := flag.String("key", "", "exact key to fetch (required for 'get' command)")
+    grep := flag.String("grep", "", "only show values matching this regular expression (for 'view' command)")
+    limit := flag.Int("limit", 100, "maximum number of keys to print for 'view' command (0 for unlimited)")
+    pretty := flag.Bool("pretty", false, "pretty-print JSON values (for 'get' command)")
+    entity := flag.String("entity", "", "decode values as this record type: 'user', 'company', 'order', 'product' or 'category' (for 'view' command)")
+    sample := flag.Int("sample", 0, "estimate the summary from about this many keys instead of reading every key (for 'summary' command, 0 for a full scan)")
+    workers := flag.Int("workers", 2, "concurrent compactions for 'flatten' command")
     flag.Parse()
 
-    db, err := badger.Open(badger.DefaultOptions(*dbPath).WithReadOnly(true))
+    // Compile the pattern before opening the database so a typo fails fast
+    var re *regexp.Regexp
+    if *grep != "" {
+        var err error
+        if re, err = regexp.Compile(*grep); err != nil {
+            log.Fatalf("Invalid -grep pattern: %v", err)
+        }
+    }
+
+    if err := checkFormatVersion(*dbPath); err != nil {
+        log.Fatalf("Incompatible database: %v", err)
+    }
+
+    db, err := badger.Open(badger.DefaultOptions(*dbPath).WithReadOnly(!writeCommands[*command]))
     if err != nil {
         log.Fatalf("Failed to open database: %v", err)
     }
@@ -23,17 +62,87 @@ func main() {
 
     switch *command {
     case "summary":
-        showDatabaseSummary(db)
+        if *sample > 0 {
+            sampleSummary(db, *sample)
+        } else {
+            showDatabaseSummary(db)
+        }
+    case "sizes":
+        showSizes(db)
     case "view":
+        prefixes := splitPrefixes(*prefix)
+        if len(prefixes) == 0 {
+            log.Fatal("Please specify a prefix using -prefix flag")
+        }
+        for _, p := range prefixes {
+            viewTableContents(db, p, re, *limit, *entity)
+        }
+    case "get":
+        if *key == "" {
+            log.Fatal("Please specify a key using -key flag")
+        }
+        getKey(db, *key, *pretty)
+    case "verify":
+        verifyDatabase(db)
+    case "backup":
+        if *out == "" {
+            log.Fatal("Please specify a backup file using -out flag")
+        }
+        backupDatabase(db, *out, *since)
+    case "restore":
+        if *in == "" {
+            log.Fatal("Please specify a backup file using -in flag")
+        }
+        restoreDatabase(db, *in)
+    case "drop":
+        if *prefix == "" {
+            log.Fatal("Please specify a prefix using -prefix flag")
+        }
+        dropPrefix(db, *prefix, *confirm)
+    case "export":
         if *prefix == "" {
             log.Fatal("Please specify a prefix using -prefix flag")
         }
-        viewTableContents(db, *prefix)
+        exportPrefix(db, *prefix, *format, *out)
+    case "import":
+        if *in == "" {
+            log.Fatal("Please specify an input file using -in flag")
+        }
+        importFile(db, *format, *in)
+    case "tail":
+        tailPrefix(db, *prefix)
+    case "flatten":
+        flattenDatabase(db, *workers)
+    case "gc":
+        collectGarbage(db)
     default:
-        log.Fatalf("Unknown command: %s. Use 'summary' or 'view'", *command)
+        log.Fatalf("Unknown command: %s. Use 'summary', 'sizes', 'view', 'get', 'verify', 'backup', 'restore', 'drop', 'export', 'import', 'tail', 'flatten' or 'gc'", *command)
     }
 }
 
+// splitPrefixes splits a comma-separated -prefix value, dropping empty entries
+func splitPrefixes(value string) []string {
+    var prefixes []string
+    for _, p := range strings.Split(value, ",") {
+        if p != "" {
+            prefixes = append(prefixes, p)
+        }
+    }
+    return prefixes
+}
+
+// extractPrefix returns the potential table name of key: everything before
+// the first ':' or, failing that, the first '/'
+func extractPrefix(key string) string {
+    if idx := strings.Index(key, ":"); idx != -1 {
+        return key[:idx]
+    }
+    if idx := strings.Index(key, "/"); idx != -1 {
+        return key[:idx]
+    }
+    return "no_prefix"
+}
+
 func showDatabaseSummary(db *badger.DB) {
     prefixes := make(map[string]int)
     
@@ -45,17 +154,7 @@ func showDatabaseSummary(db *badger.DB) {
         
         for it.Rewind(); it.Valid(); it.Next() {
             key := string(it.Item().Key())
-            
-            // Extract potential table name (everything before first : or /)
-            var prefix string
-            if idx := strings.Index(key, ":"); idx != -1 {
-                prefix = key[:idx]
-            } else if idx := strings.Index(key, "/"); idx != -1 {
-                prefix = key[:idx]
-            } else {
-                prefix = "no_prefix"
-            }
-            
+            prefix := extractPrefix(key)
             prefixes[prefix]++
             
             // Print first few keys to understand structure
@@ -76,10 +175,14 @@ func showDatabaseSummary(db *badger.DB) {
     }
 }
 
-// viewTableContents shows all key-value pairs with the given prefix
-func viewTableContents(db *badger.DB, prefix string) {
+// viewTableContents prints the keys and values under prefix. If re is not nil
+// only values it matches are considered. At most limit keys are printed unless
+// limit is 0; the rest are only counted. Values are decoded as entity when it
+// names a known record type.
+func viewTableContents(db *badger.DB, prefix string, re *regexp.Regexp, limit int, entity string) {
     fmt.Printf("\nContents of prefix '%s':\n", prefix)
-    count := 0
+    count, shown := 0, 0
+    truncated := false
     
     err := db.View(func(txn *badger.Txn) error {
         opts := badger.DefaultIteratorOptions
@@ -90,13 +193,34 @@ func viewTableContents(db *badger.DB, prefix string) {
         for it.Rewind(); it.Valid(); it.Next() {
             item := it.Item()
             key := string(item.Key())
-            val, err := item.ValueCopy(nil)
-            if err != nil {
-                fmt.Printf("Error reading value for key %s: %v\n", key, err)
-                continue
+            
+            var val []byte
+            if re != nil {
+                var err error
+                if val, err = item.ValueCopy(nil); err != nil {
+                    fmt.Printf("Error reading value for key %s: %v\n", key, err)
+                    continue
+                }
+                if !re.Match(val) {
+                    continue
+                }
             }
-            fmt.Printf("Key: %s\nValue: %s\n\n", key, val)
+            
             count++
+            if limit > 0 && shown >= limit {
+                truncated = true
+                continue
+            }
+            
+            if val == nil {
+                var err error
+                if val, err = item.ValueCopy(nil); err != nil {
+                    fmt.Printf("Error reading value for key %s: %v\n", key, err)
+                    continue
+                }
+            }
+            fmt.Printf("Key: %s\nValue: %s\n\n", key, formatValue(val, entity))
+            shown++
         }
         return nil
     })
@@ -107,7 +231,112 @@ func viewTableContents(db *badger.DB, prefix string) {
     
     if count == 0 {
         fmt.Println("No keys found with the specified prefix")
+    } else if truncated {
+        fmt.Println("... (truncated, use -limit 0 for all)")
+        fmt.Printf("Showed %d of %d keys with prefix '%s'\n", shown, count, prefix)
     } else {
         fmt.Printf("Found %d keys with prefix '%s'\n", count, prefix)
     }
 }
+
+
+// getKey prints the value of a single key. Values that parse as JSON are
+// indented when pretty is set. A missing key exits with a non-zero status.
+func getKey(db *badger.DB, key string, pretty bool) {
+    var val []byte
+    err := db.View(func(txn *badger.Txn) error {
+        item, err := txn.Get([]byte(key))
+        if err != nil {
+            return err
+        }
+        val, err = item.ValueCopy(nil)
+        return err
+    })
+    if err == badger.ErrKeyNotFound {
+        log.Fatalf("Key not found: %s", key)
+    }
+    if err != nil {
+        log.Fatalf("Error reading from database: %v", err)
+    }
+    
+    if pretty && json.Valid(val) {
+        var buf bytes.Buffer
+        if err := json.Indent(&buf, val, "", "  "); err == nil {
+            val = buf.Bytes()
+        }
+    }
+    fmt.Printf("%s\n", val)
+}
+
+// backupDatabase writes a backup of all keys newer than since to path
+func backupDatabase(db *badger.DB, path string, since uint64) {
+    f, err := os.Create(path)
+    if err != nil {
+        log.Fatalf("Failed to create backup file: %v", err)
+    }
+    defer f.Close()
+    
+    version, err := db.Backup(f, since)
+    if err != nil {
+        log.Fatalf("Error backing up database: %v", err)
+    }
+    
+    fmt.Printf("Backup written to %s (version %d)\n", path, version)
+    fmt.Printf("Use -since %d for the next incremental backup\n", version)
+}
+
+// restoreDatabase loads a backup file produced by the backup command
+func restoreDatabase(db *badger.DB, path string) {
+    f, err := os.Open(path)
+    if err != nil {
+        log.Fatalf("Failed to open backup file: %v", err)
+    }
+    defer f.Close()
+    
+    if err := db.Load(f, 256); err != nil {
+        log.Fatalf("Error restoring database: %v", err)
+    }
+    
+    fmt.Printf("Restored backup from %s\n", path)
+}
+
+// dropPrefix deletes every key with the given prefix. When the prefix names a
+// table such as "users:", the table's "counter:users" key is removed as well
+// so IDs start over.
+func dropPrefix(db *badger.DB, prefix string, confirm bool) {
+    count := 0
+    err := db.View(func(txn *badger.Txn) error {
+        opts := badger.DefaultIteratorOptions
+        opts.PrefetchValues = false
+        opts.Prefix = []byte(prefix)
+        it := txn.NewIterator(opts)
+        defer it.Close()
+        
+        for it.Rewind(); it.Valid(); it.Next() {
+            count++
+        }
+        return nil
+    })
+    if err != nil {
+        log.Fatalf("Error scanning database: %v", err)
+    }
+    
+    if !confirm {
+        log.Fatalf("Refusing to drop %d keys with prefix '%s'. Re-run with -confirm to delete them", count, prefix)
+    }
+    
+    if err := db.DropPrefix([]byte(prefix)); err != nil {
+        log.Fatalf("Error dropping prefix: %v", err)
+    }
+    
+    if table := strings.TrimSuffix(prefix, ":"); table != prefix && table != "" {
+        err := db.Update(func(txn *badger.Txn) error {
+            return txn.Delete([]byte("counter:" + table))
+        })
+        if err != nil {
+            log.Fatalf("Error resetting counter: %v", err)
+        }
+    }
+    
+    fmt.Printf("Dropped %d keys with prefix '%s'\n", count, prefix)
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+    "bytes"
+    "io"
+    "os"
+    "regexp"
+    "strings"
+    "testing"
+
+    "github.com/dgraph-io/badger/v4"
+)
+
+func TestExtractPrefix(t *testing.T) {
+    tests := []struct {
+        key  string
+        want string
+    }{
+        {"users:1", "users"},
+        {"idx:users:email:a@b.c", "idx"},
+        {"logs/2024/01", "logs"},
+        {"a/b:c", "a/b"},
+        {":leading", ""},
+        {"/leading", ""},
+        {":", ""},
+        {"", "no_prefix"},
+        {"plain", "no_prefix"},
+        {"ключ:1", "ключ"},
+    }
+    
+    for _, tt := range tests {
+        if got := extractPrefix(tt.key); got != tt.want {
+            t.Errorf("extractPrefix(%q) = %q, want %q", tt.key, got, tt.want)
+        }
+    }
+}
+
+func FuzzExtractPrefix(f *testing.F) {
+    for _, seed := range []string{"users:1", "logs/2024", "", ":", "/", "a/b:c", "ключ:1", "\xff:\x00"} {
+        f.Add(seed)
+    }
+    
+    f.Fuzz(func(t *testing.T, key string) {
+        got := extractPrefix(key)
+        if !strings.ContainsAny(key, ":/") {
+            if got != "no_prefix" {
+                t.Fatalf("extractPrefix(%q) = %q, want no_prefix", key, got)
+            }
+            return
+        }
+        
+        if !strings.HasPrefix(key, got) {
+            t.Fatalf("extractPrefix(%q) = %q, not a prefix of the key", key, got)
+        }
+        if strings.Contains(got, ":") {
+            t.Fatalf("extractPrefix(%q) = %q, contains the separator", key, got)
+        }
+        if !strings.Contains(key, ":") && strings.Contains(got, "/") {
+            t.Fatalf("extractPrefix(%q) = %q, contains the separator", key, got)
+        }
+    })
+}
+
+// openTestDB opens an in-memory database holding values, closed when the
+// test ends
+func openTestDB(t *testing.T, values map[string]string) *badger.DB {
+    t.Helper()
+    
+    db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+    if err != nil {
+        t.Fatalf("badger.Open: %v", err)
+    }
+    t.Cleanup(func() { db.Close() })
+    
+    err = db.Update(func(txn *badger.Txn) error {
+        for key, value := range values {
+            if err := txn.Set([]byte(key), []byte(value)); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        t.Fatalf("seeding the database: %v", err)
+    }
+    return db
+}
+
+// readAll returns every key and value of db
+func readAll(t *testing.T, db *badger.DB) map[string]string {
+    t.Helper()
+    
+    values := make(map[string]string)
+    err := db.View(func(txn *badger.Txn) error {
+        it := txn.NewIterator(badger.DefaultIteratorOptions)
+        defer it.Close()
+        
+        for it.Rewind(); it.Valid(); it.Next() {
+            val, err := it.Item().ValueCopy(nil)
+            if err != nil {
+                return err
+            }
+            values[string(it.Item().Key())] = string(val)
+        }
+        return nil
+    })
+    if err != nil {
+        t.Fatalf("reading the database: %v", err)
+    }
+    return values
+}
+
+func TestNDJSONRoundTrip(t *testing.T) {
+    users := map[string]string{
+        "users:1": `{"id":1,"name":"Alice","tags":["a","b"]}`,
+        "users:2": `{"id":2,"name":"Bob \"B\" é","email":null}`,
+        "users:3": `"just a string"`,
+    }
+    src := openTestDB(t, map[string]string{
+        "users:1":       users["users:1"],
+        "users:2":       users["users:2"],
+        "users:3":       users["users:3"],
+        "users:4":       "not json",
+        "orders:1":      `{"id":1}`,
+        "counter:users": "\x00\x00\x00\x00\x00\x00\x00\x04",
+    })
+    
+    var buf bytes.Buffer
+    n, err := exportNDJSON(src, "users:", &buf)
+    if err != nil {
+        t.Fatalf("exportNDJSON: %v", err)
+    }
+    if n != 3 {
+        t.Errorf("exported %d records, want 3", n)
+    }
+    
+    // Trailing blank lines, as left by editors and shell redirects
+    buf.WriteString("\n\n  \n")
+    
+    dst := openTestDB(t, nil)
+    n, err = importNDJSON(dst, &buf)
+    if err != nil {
+        t.Fatalf("importNDJSON: %v", err)
+    }
+    if n != 3 {
+        t.Errorf("imported %d records, want 3", n)
+    }
+    
+    got := readAll(t, dst)
+    if len(got) != len(users) {
+        t.Errorf("imported keys %v, want %v", got, users)
+    }
+    for key, want := range users {
+        if got[key] != want {
+            t.Errorf("%s = %s, want %s", key, got[key], want)
+        }
+    }
+}
+
+func TestImportNDJSONRejectsMalformedLines(t *testing.T) {
+    for _, input := range []string{
+        "{\"key\":\"users:1\",\"value\":{}}\n{\"key\":",
+        "{\"value\":{}}\n",
+        "{\"key\":\"users:1\"}\n",
+    } {
+        db := openTestDB(t, nil)
+        if _, err := importNDJSON(db, strings.NewReader(input)); err == nil {
+            t.Errorf("importNDJSON(%q) succeeded, want an error", input)
+        }
+        if got := readAll(t, db); len(got) != 0 {
+            t.Errorf("importNDJSON(%q) wrote %v despite the error", input, got)
+        }
+    }
+}
+
+func TestFormatValue(t *testing.T) {
+    // Values as the multi-table example stores its seeded users and orders
+    tests := []struct {
+        name, val, entity, want string
+    }{
+        {
+            "user",
+            `{"id":1,"name":"Alice Smith","email":"alice@example.com","company_id":1,"created_at":"2024-01-02T03:04:05.123456789Z"}`,
+            "user",
+            "\n  ID: 1\n  Name: Alice Smith\n  Email: alice@example.com\n  CompanyID: 1\n  CreatedAt: 2024-01-02T03:04:05Z",
+        },
+        {
+            "order with line items",
+            `{"id":5,"user_id":1,"product_id":0,"quantity":0,"line_items":[{"product_id":1,"quantity":2,"unit_price":9.5}],"amount":19,"status":"pending","created_at":"2024-01-02T03:04:05+02:00"}`,
+            "order",
+            "\n  ID: 5\n  UserID: 1\n  ProductID: 0\n  Quantity: 0\n  LineItems:\n    - ProductID: 1, Quantity: 2, UnitPrice: 9.5\n  Amount: 19\n  Status: pending\n  CreatedAt: 2024-01-02T03:04:05+02:00",
+        },
+        {
+            "order without line items",
+            `{"id":1,"user_id":1,"product_id":1,"quantity":1,"amount":999.99,"status":"completed","created_at":"2024-01-02T03:04:05Z"}`,
+            "order",
+            "\n  ID: 1\n  UserID: 1\n  ProductID: 1\n  Quantity: 1\n  Amount: 999.99\n  Status: completed\n  CreatedAt: 2024-01-02T03:04:05Z",
+        },
+        {"unknown field", `{"id":1,"nickname":"Al"}`, "user", `{"id":1,"nickname":"Al"}`},
+        {"not JSON", "\x00\x00\x00\x03", "user", "\x00\x00\x00\x03"},
+        {"wrong type", `{"id":"one"}`, "user", `{"id":"one"}`},
+        {"no entity", `{"id":1}`, "", `{"id":1}`},
+        {"unknown entity", `{"id":1}`, "widget", `{"id":1}`},
+    }
+    
+    for _, tt := range tests {
+        if got := formatValue([]byte(tt.val), tt.entity); got != tt.want {
+            t.Errorf("%s: formatValue = %q, want %q", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestCollectSizes(t *testing.T) {
+    db := openTestDB(t, map[string]string{
+        "users:1":       strings.Repeat("a", 100),
+        "users:2":       strings.Repeat("b", 300),
+        "users:3":       strings.Repeat("c", 20000),
+        "orders:1":      strings.Repeat("d", 1024),
+        "counter:users": "\x00\x00\x00\x00\x00\x00\x00\x03",
+    })
+    
+    sizes, err := collectSizes(db)
+    if err != nil {
+        t.Fatalf("collectSizes: %v", err)
+    }
+    
+    want := map[string]prefixSizes{
+        "users":   {Count: 3, Total: 20400, Min: 100, Max: 20000, Histogram: []int{1, 1, 0, 1}},
+        "orders":  {Count: 1, Total: 1024, Min: 1024, Max: 1024, Histogram: []int{0, 0, 1, 0}},
+        "counter": {Count: 1, Total: 8, Min: 8, Max: 8, Histogram: []int{1, 0, 0, 0}},
+    }
+    if len(sizes) != len(want) {
+        t.Fatalf("got prefixes %v, want %d", sizes, len(want))
+    }
+    for prefix, w := range want {
+        got, ok := sizes[prefix]
+        if !ok {
+            t.Errorf("%s: missing", prefix)
+            continue
+        }
+        if got.Count != w.Count || got.Total != w.Total || got.Min != w.Min || got.Max != w.Max {
+            t.Errorf("%s: got %+v, want %+v", prefix, *got, w)
+        }
+        for i := range w.Histogram {
+            if got.Histogram[i] != w.Histogram[i] {
+                t.Errorf("%s: got histogram %v, want %v", prefix, got.Histogram, w.Histogram)
+                break
+            }
+        }
+    }
+}
+
+// captureStdout returns what fn prints to standard output
+func captureStdout(t *testing.T, fn func()) string {
+    t.Helper()
+    
+    r, w, err := os.Pipe()
+    if err != nil {
+        t.Fatalf("os.Pipe: %v", err)
+    }
+    stdout := os.Stdout
+    os.Stdout = w
+    defer func() { os.Stdout = stdout }()
+    
+    out := make(chan string)
+    go func() {
+        b, _ := io.ReadAll(r)
+        out <- string(b)
+    }()
+    
+    fn()
+    w.Close()
+    return <-out
+}
+
+func TestViewTableContentsGrep(t *testing.T) {
+    db := openTestDB(t, map[string]string{
+        "users:1":  `{"id":1,"name":"Alice","email":"alice@example.com"}`,
+        "users:2":  `{"id":2,"name":"Bob","email":"bob@corp.io"}`,
+        "users:3":  `{"id":3,"name":"Charlie","email":"charlie@example.com"}`,
+        "orders:1": `{"id":1,"email":"orders@example.com"}`,
+    })
+    
+    out := captureStdout(t, func() {
+        viewTableContents(db, "users:", regexp.MustCompile(`"email":"[^"]*@example\.com"`), 0, "")
+    })
+    for _, key := range []string{"Key: users:1\n", "Key: users:3\n"} {
+        if !strings.Contains(out, key) {
+            t.Errorf("output lacks %q:\n%s", key, out)
+        }
+    }
+    for _, key := range []string{"users:2", "orders:1"} {
+        if strings.Contains(out, key) {
+            t.Errorf("output has %s:\n%s", key, out)
+        }
+    }
+    if !strings.Contains(out, "Found 2 keys with prefix 'users:'") {
+        t.Errorf("output lacks the count of 2:\n%s", out)
+    }
+    
+    out = captureStdout(t, func() {
+        viewTableContents(db, "users:", regexp.MustCompile("nobody"), 0, "")
+    })
+    if !strings.Contains(out, "No keys found") {
+        t.Errorf("a pattern matching nothing printed:\n%s", out)
+    }
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+    "bytes"
+    "fmt"
+    "log"
+    "sort"
+
+    "github.com/dgraph-io/badger/v4"
+    "github.com/dgraph-io/badger/v4/y"
+)
+
+// sampleSummary estimates the number of keys per prefix from the SST tables
+// instead of reading every key. A table whose first and last key share a
+// prefix is counted towards it whole from its key count, without reading it.
+// Tables spanning several prefixes share about n key reads: each is read from
+// its first key, exactly if it fits, otherwise the prefixes seen are scaled up
+// to the table's key count. Databases with fewer than n keys in their tables
+// get the full summary.
+func sampleSummary(db *badger.DB, n int) {
+    tables := db.Tables()
+    var total uint64
+    var mixed []badger.TableInfo
+    for _, t := range tables {
+        total += uint64(t.KeyCount)
+        if left, right := tablePrefixes(t); left != right {
+            mixed = append(mixed, t)
+        }
+    }
+    if len(tables) == 0 || total <= uint64(n) {
+        showDatabaseSummary(db)
+        return
+    }
+    
+    estimates := make(map[string]float64)
+    for _, t := range tables {
+        if left, right := tablePrefixes(t); left == right {
+            estimates[left] += float64(t.KeyCount)
+        }
+    }
+    
+    perTable := max(n/max(len(mixed), 1), 1)
+    sampled := 0
+    err := db.View(func(txn *badger.Txn) error {
+        opts := badger.DefaultIteratorOptions
+        opts.PrefetchValues = false // Only need keys
+        it := txn.NewIterator(opts)
+        defer it.Close()
+        
+        for _, t := range mixed {
+            right := y.ParseKey(t.Right)
+            counts := make(map[string]int)
+            read, complete := 0, false
+            for it.Seek(y.ParseKey(t.Left)); it.Valid(); it.Next() {
+                key := it.Item().Key()
+                if bytes.Compare(key, right) > 0 {
+                    complete = true
+                    break
+                }
+                if read == perTable {
+                    break
+                }
+                counts[extractPrefix(string(key))]++
+                read++
+            }
+            complete = complete || !it.Valid()
+            
+            scale := 1.0
+            if !complete && read > 0 {
+                scale = float64(t.KeyCount) / float64(read)
+            }
+            for prefix, count := range counts {
+                estimates[prefix] += float64(count) * scale
+            }
+            sampled += read
+        }
+        return nil
+    })
+    if err != nil {
+        log.Fatalf("Error scanning database: %v", err)
+    }
+    
+    prefixes := make([]string, 0, len(estimates))
+    for prefix := range estimates {
+        prefixes = append(prefixes, prefix)
+    }
+    sort.Strings(prefixes)
+    
+    fmt.Printf("Key prefixes estimate (about %d keys in %d tables, %d keys read):\n", total, len(tables), sampled)
+    for _, prefix := range prefixes {
+        fmt.Printf("%s: ~%.0f keys\n", prefix, estimates[prefix])
+    }
+    fmt.Printf("\nTables holding a single prefix were counted from their key count. The %d tables\n", len(mixed))
+    fmt.Printf("spanning several prefixes were read from their first key, up to %d keys each,\n", perTable)
+    fmt.Println("and scaled up to their key count. Key counts include old versions and deleted")
+    fmt.Println("keys not yet compacted away, and keys still in memory are not counted.")
+}
+
+// tablePrefixes returns the prefixes of the first and last key of a table
+func tablePrefixes(t badger.TableInfo) (string, string) {
+    return extractPrefix(string(y.ParseKey(t.Left))), extractPrefix(string(y.ParseKey(t.Right)))
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+    "fmt"
+    "log"
+    "sort"
+
+    "github.com/dgraph-io/badger/v4"
+)
+
+// sizeBuckets are the upper bounds of the value size histogram; values at or
+// above the last bound fall into a final open-ended bucket
+var sizeBuckets = []int64{256, 1 << 10, 16 << 10}
+
+var sizeBucketLabels = []string{"<256B", "<1K", "<16K", ">=16K"}
+
+// prefixSizes holds the value size statistics of one prefix
+type prefixSizes struct {
+    Count     int
+    Total     int64
+    Min       int64
+    Max       int64
+    Histogram []int
+}
+
+func (p *prefixSizes) add(size int64) {
+    if p.Count == 0 || size < p.Min {
+        p.Min = size
+    }
+    if size > p.Max {
+        p.Max = size
+    }
+    p.Count++
+    p.Total += size
+    
+    bucket := len(sizeBuckets)
+    for i, bound := range sizeBuckets {
+        if size < bound {
+            bucket = i
+            break
+        }
+    }
+    p.Histogram[bucket]++
+}
+
+// collectSizes gathers value size statistics per prefix. It reads
+// ValueSize, so no value is fetched from the value log.
+func collectSizes(db *badger.DB) (map[string]*prefixSizes, error) {
+    sizes := make(map[string]*prefixSizes)
+    
+    err := db.View(func(txn *badger.Txn) error {
+        opts := badger.DefaultIteratorOptions
+        opts.PrefetchValues = false // ValueSize doesn't need the value
+        it := txn.NewIterator(opts)
+        defer it.Close()
+        
+        for it.Rewind(); it.Valid(); it.Next() {
+            item := it.Item()
+            prefix := extractPrefix(string(item.Key()))
+            
+            p, ok := sizes[prefix]
+            if !ok {
+                p = &prefixSizes{Histogram: make([]int, len(sizeBucketLabels))}
+                sizes[prefix] = p
+            }
+            p.add(item.ValueSize())
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    
+    return sizes, nil
+}
+
+// showSizes prints the value size statistics of every prefix
+func showSizes(db *badger.DB) {
+    sizes, err := collectSizes(db)
+    if err != nil {
+        log.Fatalf("Error scanning database: %v", err)
+    }
+    
+    prefixes := make([]string, 0, len(sizes))
+    for prefix := range sizes {
+        prefixes = append(prefixes, prefix)
+    }
+    sort.Strings(prefixes)
+    
+    for _, prefix := range prefixes {
+        p := sizes[prefix]
+        fmt.Printf("%s: %d keys, %d value bytes (min %d, max %d, avg %d)\n",
+            prefix, p.Count, p.Total, p.Min, p.Max, p.Total/int64(p.Count))
+        for i, label := range sizeBucketLabels {
+            fmt.Printf("  %-6s %d\n", label, p.Histogram[i])
+        }
+    }
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "os"
+    "os/signal"
+    "syscall"
+
+    "github.com/dgraph-io/badger/v4"
+    "github.com/dgraph-io/badger/v4/pb"
+)
+
+// tailPrefix prints each key written under prefix until interrupted. An
+// empty prefix watches the whole database.
+//
+// Subscribing needs a writable handle, which takes Badger's directory lock.
+// tail therefore fails to start while another process holds the database
+// open, and no other process can open it while tail runs. Badger only
+// reports writes made through this process's own handle.
+func tailPrefix(db *badger.DB, prefix string) {
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+
+    fmt.Printf("Watching prefix '%s', press Ctrl-C to stop\n", prefix)
+
+    err := db.Subscribe(ctx, func(list *badger.KVList) error {
+        for _, kv := range list.Kv {
+            fmt.Printf("Key: %s\nValue: %s\n\n", kv.Key, kv.Value)
+        }
+        return nil
+    }, []pb.Match{{Prefix: []byte(prefix)}})
+    if err != nil && err != ctx.Err() {
+        log.Fatalf("Error watching database: %v", err)
+    }
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+    "bytes"
+    "encoding/binary"
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "sort"
+    "strconv"
+    
+    "github.com/dgraph-io/badger/v4"
+)
+
+// reference is a JSON field of the records under one prefix holding the ID of
+// a record under another prefix
+type reference struct {
+    entity string
+    field  string
+    target string
+    name   string
+}
+
+// references are the links checked by verify, matching the records of the
+// multi-table example. Orders with line items reference a product per line.
+var references = []reference{
+    {entity: "users", field: "company_id", target: "companies", name: "company"},
+    {entity: "orders", field: "user_id", target: "users", name: "user"},
+    {entity: "orders", field: "product_id", target: "products", name: "product"},
+    {entity: "products", field: "category_id", target: "categories", name: "category"},
+}
+
+// counterEntities are the entities whose ID counter is checked
+var counterEntities = []string{"users", "companies", "orders", "products", "categories"}
+
+// verifyDatabase prints every integrity problem found and exits with a
+// non-zero status if there is any
+func verifyDatabase(db *badger.DB) {
+    problems, err := findProblems(db)
+    if err != nil {
+        log.Fatalf("Error verifying database: %v", err)
+    }
+    
+    if len(problems) == 0 {
+        fmt.Println("No problems found")
+        return
+    }
+    for _, problem := range problems {
+        fmt.Println(problem)
+    }
+    fmt.Printf("Found %d problems\n", len(problems))
+    os.Exit(1)
+}
+
+// findProblems checks the references between the JSON records and the ID
+// counters, all in one read transaction
+func findProblems(db *badger.DB) ([]string, error) {
+    var problems []string
+    
+    err := db.View(func(txn *badger.Txn) error {
+        records := make(map[string]map[int64]map[string]json.RawMessage)
+        for _, entity := range counterEntities {
+            recs, err := readRecords(txn, entity)
+            if err != nil {
+                return err
+            }
+            records[entity] = recs
+        }
+        
+        for _, ref := range references {
+            for _, id := range sortedIDs(records[ref.entity]) {
+                for _, target := range referencedIDs(records[ref.entity][id], ref.field) {
+                    if _, ok := records[ref.target][target]; !ok {
+                        problems = append(problems, fmt.Sprintf("%s %d: %s %d does not exist", ref.entity, id, ref.name, target))
+                    }
+                }
+            }
+        }
+        
+        for _, entity := range counterEntities {
+            counter, err := readCounter(txn, []byte("counter:"+entity))
+            if err != nil {
+                return err
+            }
+            
+            var maxID int64
+            for id := range records[entity] {
+                maxID = max(maxID, id)
+            }
+            if counter < maxID {
+                problems = append(problems, fmt.Sprintf("counter of %s is %d, below the highest ID %d", entity, counter, maxID))
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    
+    return problems, nil
+}
+
+// readRecords decodes the JSON records stored under "<entity>:<id>"
+func readRecords(txn *badger.Txn, entity string) (map[int64]map[string]json.RawMessage, error) {
+    opts := badger.DefaultIteratorOptions
+    opts.Prefix = []byte(entity + ":")
+    it := txn.NewIterator(opts)
+    defer it.Close()
+    
+    records := make(map[int64]map[string]json.RawMessage)
+    for it.Rewind(); it.Valid(); it.Next() {
+        item := it.Item()
+        id, err := strconv.ParseInt(string(item.Key()[len(opts.Prefix):]), 10, 64)
+        if err != nil {
+            continue
+        }
+        
+        var record map[string]json.RawMessage
+        err = item.Value(func(val []byte) error {
+            return json.Unmarshal(val, &record)
+        })
+        if err != nil {
+            return nil, fmt.Errorf("%s is not a JSON record: %w", item.Key(), err)
+        }
+        records[id] = record
+    }
+    
+    return records, nil
+}
+
+// referencedIDs returns the IDs in field of record. For product_id, the
+// products of an order's line items take precedence over the field itself.
+func referencedIDs(record map[string]json.RawMessage, field string) []int64 {
+    if field == "product_id" {
+        var items []map[string]json.RawMessage
+        if json.Unmarshal(record["line_items"], &items) == nil && len(items) > 0 {
+            var ids []int64
+            for _, item := range items {
+                ids = append(ids, referencedIDs(item, field)...)
+            }
+            return ids
+        }
+    }
+    
+    var id int64
+    if err := json.Unmarshal(record[field], &id); err != nil {
+        return nil
+    }
+    return []int64{id}
+}
+
+// readCounter reads an ID counter the way the service does: the newest
+// version is either a legacy JSON number or an 8-byte big-endian value, and
+// binary versions are summed down to the one that discards earlier versions
+func readCounter(txn *badger.Txn, key []byte) (int64, error) {
+    opts := badger.DefaultIteratorOptions
+    opts.AllVersions = true
+    it := txn.NewKeyIterator(key, opts)
+    defer it.Close()
+    
+    var counter int64
+    for it.Rewind(); it.Valid(); it.Next() {
+        item := it.Item()
+        if item.IsDeletedOrExpired() {
+            break
+        }
+        
+        val, err := item.ValueCopy(nil)
+        if err != nil {
+            return 0, err
+        }
+        
+        if len(val) > 0 && val[0] != 0 {
+            base, err := strconv.ParseInt(string(bytes.TrimSpace(val)), 10, 64)
+            if err != nil {
+                return 0, fmt.Errorf("%s is not a counter: %w", key, err)
+            }
+            return counter + base, nil
+        }
+        
+        if len(val) == 8 {
+            counter += int64(binary.BigEndian.Uint64(val))
+        }
+        if item.DiscardEarlierVersions() {
+            break
+        }
+    }
+    
+    return counter, nil
+}
+
+// sortedIDs returns the IDs of records in ascending order
+func sortedIDs(records map[int64]map[string]json.RawMessage) []int64 {
+    ids := make([]int64, 0, len(records))
+    for id := range records {
+        ids = append(ids, id)
+    }
+    sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+    return ids
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// AggOp selects what Aggregate computes
+type AggOp int
+
+const (
+	AggSum AggOp = iota
+	AggAvg
+	AggMin
+	AggMax
+	AggCount
+)
+
+// aggregate accumulates the values Aggregate and GroupBy compute an AggOp over
+type aggregate struct {
+	sum, min, max float64
+	count         int
+}
+
+func (a *aggregate) add(value float64) {
+	if a.count == 0 || value < a.min {
+		a.min = value
+	}
+	if a.count == 0 || value > a.max {
+		a.max = value
+	}
+	a.sum += value
+	a.count++
+}
+
+func (a *aggregate) result(op AggOp) float64 {
+	switch op {
+	case AggSum:
+		return a.sum
+	case AggAvg:
+		if a.count == 0 {
+			return 0
+		}
+		return a.sum / float64(a.count)
+	case AggMin:
+		return a.min
+	case AggMax:
+		return a.max
+	default:
+		return float64(a.count)
+	}
+}
+
+// Aggregate computes op over the numeric jsonField of every record of entity
+// in one scan, e.g. Aggregate("orders", "amount", AggSum). Records without the
+// field, or where it is not a number, are skipped, so AggCount counts the
+// records that have it. With no such record the result is 0.
+func (s *BadgerService) Aggregate(entity, jsonField string, op AggOp) (float64, error) {
+	var agg aggregate
+	err := s.eachJSONRecord(entity, op, func(record map[string]interface{}) {
+		if value, ok := record[jsonField].(float64); ok {
+			agg.add(value)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	
+	return agg.result(op), nil
+}
+
+// GroupBy computes op over the numeric valueField of the records of entity
+// per value of groupField, e.g. GroupBy("orders", "status", "amount", AggSum)
+// for the revenue per status. Number and boolean group values are keyed by
+// their JSON text. Records missing either field, or whose group value is an
+// object, array or null, are skipped like in Aggregate.
+func (s *BadgerService) GroupBy(entity, groupField, valueField string, op AggOp) (map[string]float64, error) {
+	groups := make(map[string]*aggregate)
+	err := s.eachJSONRecord(entity, op, func(record map[string]interface{}) {
+		value, ok := record[valueField].(float64)
+		if !ok {
+			return
+		}
+		
+		var group string
+		switch g := record[groupField].(type) {
+		case string:
+			group = g
+		case float64:
+			group = strconv.FormatFloat(g, 'f', -1, 64)
+		case bool:
+			group = strconv.FormatBool(g)
+		default:
+			return
+		}
+		
+		if groups[group] == nil {
+			groups[group] = &aggregate{}
+		}
+		groups[group].add(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	result := make(map[string]float64, len(groups))
+	for group, agg := range groups {
+		result[group] = agg.result(op)
+	}
+	return result, nil
+}
+
+// eachJSONRecord checks the arguments of an aggregation and passes every
+// record of entity to fn decoded into a generic map, all in one read
+// transaction
+func (s *BadgerService) eachJSONRecord(entity string, op AggOp, fn func(record map[string]interface{})) error {
+	if _, ok := entityTypes[entity]; !ok {
+		return fmt.Errorf("no record type registered for %s", entity)
+	}
+	if op < AggSum || op > AggCount {
+		return fmt.Errorf("unknown aggregation %d", op)
+	}
+	
+	return s.view(func(txn *badger.Txn) error {
+		deleted, err := s.softDeletedIDs(txn, entity)
+		if err != nil {
+			return err
+		}
+		
+		opts := s.iteratorOptions()
+		opts.Prefix = s.entityPrefix(entity)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		
+		for it.Rewind(); it.Valid(); it.Next() {
+			if isSoftDeletedKey(deleted, it.Item().Key(), opts.Prefix) {
+				continue
+			}
+			
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			
+			jsonVal, err := s.toJSON(entity, val)
+			if err != nil {
+				return err
+			}
+			
+			var record map[string]interface{}
+			if err := json.Unmarshal(jsonVal, &record); err != nil {
+				return err
+			}
+			fn(record)
+		}
+		return nil
+	})
+}
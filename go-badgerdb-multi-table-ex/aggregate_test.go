@@ -0,0 +1,98 @@
+package main
+
+import "testing"
+
+func TestAggregate(t *testing.T) {
+	s := newTestService(t)
+	
+	// The seeded orders amount to 999.99, 39.98, 49.99 and 999.99
+	for _, tc := range []struct {
+		op   AggOp
+		want float64
+	}{
+		{AggSum, 2089.95},
+		{AggAvg, 522.4875},
+		{AggMin, 39.98},
+		{AggMax, 999.99},
+		{AggCount, 4},
+	} {
+		got, err := s.Aggregate("orders", "amount", tc.op)
+		if err != nil {
+			t.Fatalf("Aggregate op %d: %v", tc.op, err)
+		}
+		if !almostEqual(got, tc.want) {
+			t.Errorf("Aggregate op %d = %v, want %v", tc.op, got, tc.want)
+		}
+	}
+}
+
+func TestAggregateSkipsMissingAndNonNumericFields(t *testing.T) {
+	s := newTestService(t)
+	
+	for _, field := range []string{"discount", "status"} {
+		for _, op := range []AggOp{AggSum, AggMin, AggCount} {
+			got, err := s.Aggregate("orders", field, op)
+			if err != nil || got != 0 {
+				t.Errorf("Aggregate(%s) op %d = %v, %v, want 0", field, op, got, err)
+			}
+		}
+	}
+}
+
+func TestAggregateErrors(t *testing.T) {
+	s := newTestService(t)
+	
+	if _, err := s.Aggregate("invoices", "amount", AggSum); err == nil {
+		t.Error("an unknown entity was accepted")
+	}
+	if _, err := s.Aggregate("orders", "amount", AggCount+1); err == nil {
+		t.Error("an unknown aggregation was accepted")
+	}
+}
+
+func TestGroupByStatusRevenue(t *testing.T) {
+	s := newTestService(t)
+	
+	revenue, err := s.GroupBy("orders", "status", "amount", AggSum)
+	if err != nil {
+		t.Fatalf("GroupBy: %v", err)
+	}
+	want := map[string]float64{"completed": 999.99 + 39.98 + 999.99, "pending": 49.99}
+	if len(revenue) != len(want) {
+		t.Fatalf("GroupBy = %v, want %v", revenue, want)
+	}
+	for status, w := range want {
+		if !almostEqual(revenue[status], w) {
+			t.Errorf("revenue of %s = %v, want %v", status, revenue[status], w)
+		}
+	}
+	
+	// The per-status totals match the company stats filtered by status
+	for status := range want {
+		stats, err := s.GetCompanyStatsByStatus(status)
+		if err != nil {
+			t.Fatalf("GetCompanyStatsByStatus(%s): %v", status, err)
+		}
+		if !almostEqual(totalRevenue(stats), revenue[status]) {
+			t.Errorf("company stats of %s add up to %v, want %v", status, totalRevenue(stats), revenue[status])
+		}
+	}
+}
+
+func TestGroupByNumericField(t *testing.T) {
+	s := newTestService(t)
+	
+	counts, err := s.GroupBy("orders", "user_id", "amount", AggCount)
+	if err != nil {
+		t.Fatalf("GroupBy: %v", err)
+	}
+	want := map[string]float64{"1": 2, "2": 1, "3": 1}
+	if len(counts) != len(want) {
+		t.Fatalf("GroupBy = %v, want %v", counts, want)
+	}
+	for user, w := range want {
+		if counts[user] != w {
+			t.Errorf("orders of user %s = %v, want %v", user, counts[user], w)
+		}
+	}
+}
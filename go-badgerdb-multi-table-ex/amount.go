@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math"
+	"sort"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// amountTolerance is how far a stored order amount may be from the total of
+// its line items before ValidateOrderAmounts reports it
+const amountTolerance = 0.005
+
+// LinesTotal returns the sum of the line totals of the order
+func (o *Order) LinesTotal() float64 {
+	var total float64
+	for _, item := range o.Lines() {
+		total += item.Total()
+	}
+	return total
+}
+
+// RecalculateOrderAmount sets the amount of an order to the total of its line
+// items and returns the new amount. Reading and writing the order happen in
+// one transaction.
+func (s *BadgerService) RecalculateOrderAmount(orderID int64) (float64, error) {
+	var amount float64
+	
+	err := s.writeTxn(func(txn *badger.Txn) error {
+		item, err := txn.Get(s.recordKey("orders", orderID))
+		if err == badger.ErrKeyNotFound {
+			return notFound("orders", orderID, err)
+		}
+		if err != nil {
+			return err
+		}
+		
+		var order Order
+		err = item.Value(func(val []byte) error {
+			return s.codec.Unmarshal(val, &order)
+		})
+		if err != nil {
+			return err
+		}
+		
+		order.Amount = order.LinesTotal()
+		amount = order.Amount
+		return s.setRecord(txn, "orders", orderID, &order)
+	})
+	if err != nil {
+		return 0, err
+	}
+	
+	s.invalidateStatsFor("orders")
+	return amount, nil
+}
+
+// ValidateOrderAmounts returns the orders whose stored amount differs from
+// the total of their line items, sorted by ID
+func (s *BadgerService) ValidateOrderAmounts() ([]Order, error) {
+	var orders []Order
+	if err := s.list("orders", &orders); err != nil {
+		return nil, err
+	}
+	
+	var mismatched []Order
+	for i := range orders {
+		if math.Abs(orders[i].Amount-orders[i].LinesTotal()) > amountTolerance {
+			mismatched = append(mismatched, orders[i])
+		}
+	}
+	
+	sort.Slice(mismatched, func(i, j int) bool { return mismatched[i].ID < mismatched[j].ID })
+	return mismatched, nil
+}
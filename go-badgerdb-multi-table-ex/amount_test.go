@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateAndRecalculateOrderAmounts(t *testing.T) {
+	s := newTestService(t)
+	
+	lines := []LineItem{
+		{ProductID: 1, Quantity: 2, UnitPrice: 999.99},
+		{ProductID: 2, Quantity: 1, UnitPrice: 49.99},
+	}
+	correct := &Order{UserID: 1, ProductID: 1, LineItems: lines, Amount: 2049.97, Status: "pending"}
+	if err := s.CreateOrder(correct); err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	drifted := &Order{UserID: 2, ProductID: 1, LineItems: lines, Amount: 2000, Status: "pending"}
+	if err := s.CreateOrder(drifted); err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	
+	// The seeded orders have no line items and always match their amount
+	mismatched, err := s.ValidateOrderAmounts()
+	if err != nil {
+		t.Fatalf("ValidateOrderAmounts: %v", err)
+	}
+	if len(mismatched) != 1 || mismatched[0].ID != drifted.ID {
+		t.Fatalf("ValidateOrderAmounts = %+v, want only order %d", mismatched, drifted.ID)
+	}
+	
+	amount, err := s.RecalculateOrderAmount(drifted.ID)
+	if err != nil {
+		t.Fatalf("RecalculateOrderAmount: %v", err)
+	}
+	if !almostEqual(amount, 2049.97) {
+		t.Errorf("RecalculateOrderAmount = %.2f, want 2049.97", amount)
+	}
+	stored, err := s.Orders().Get(drifted.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !almostEqual(stored.Amount, 2049.97) {
+		t.Errorf("stored amount = %.2f, want 2049.97", stored.Amount)
+	}
+	
+	// Recalculating an order that already matches changes nothing
+	amount, err = s.RecalculateOrderAmount(correct.ID)
+	if err != nil || !almostEqual(amount, 2049.97) {
+		t.Errorf("RecalculateOrderAmount(correct) = %.2f, %v, want 2049.97", amount, err)
+	}
+	
+	if mismatched, err := s.ValidateOrderAmounts(); err != nil || len(mismatched) != 0 {
+		t.Errorf("ValidateOrderAmounts after recalculating = %+v, %v, want none", mismatched, err)
+	}
+}
+
+func TestRecalculateOrderAmountNotFound(t *testing.T) {
+	s := newTestService(t)
+	
+	if _, err := s.RecalculateOrderAmount(42); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RecalculateOrderAmount(42) = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// AuditEvent records one mutation of an entity. Diff maps every changed
+// field to its [old, new] values; a created record has null old values and a
+// deleted one null new values.
+type AuditEvent struct {
+	Op        string                        `json:"op"`
+	Entity    string                        `json:"entity"`
+	EntityID  int64                         `json:"entity_id"`
+	Diff      map[string][2]json.RawMessage `json:"diff"`
+	Timestamp time.Time                     `json:"timestamp"`
+}
+
+// auditSeq breaks ties between events written in the same nanosecond, so
+// their keys still sort in the order they were written
+var auditSeq atomic.Uint64
+
+// auditKey builds "audit:<timestamp-nanos>-<seq>", zero padded so that keys
+// sort chronologically
+func (s *BadgerService) auditKey(ts time.Time, seq uint64) []byte {
+	nanos := ts.UnixNano()
+	if nanos < 0 {
+		nanos = 0
+	}
+	return s.scopedKey(s.joinKey("audit", fmt.Sprintf("%020d-%020d", nanos, seq)))
+}
+
+// writeAudit records op on entity:id as part of txn. before and after are the
+// encoded record, nil when it does not exist on that side of the change.
+func (s *BadgerService) writeAudit(txn *badger.Txn, op, entity string, id int64, before, after []byte) error {
+	key, data, err := s.auditEntry(op, entity, id, before, after)
+	if err != nil {
+		return err
+	}
+	return txn.Set(key, data)
+}
+
+// auditEntry builds the key and value of the event writeAudit records, for
+// writes that go through a write batch instead of a transaction
+func (s *BadgerService) auditEntry(op, entity string, id int64, before, after []byte) ([]byte, []byte, error) {
+	diff, err := jsonDiff(before, after)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	event := AuditEvent{
+		Op:        op,
+		Entity:    entity,
+		EntityID:  id,
+		Diff:      diff,
+		Timestamp: s.nowFn(),
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	return s.auditKey(event.Timestamp, auditSeq.Add(1)), data, nil
+}
+
+// jsonDiff compares two JSON objects field by field
+func jsonDiff(before, after []byte) (map[string][2]json.RawMessage, error) {
+	var old, updated map[string]json.RawMessage
+	if before != nil {
+		if err := json.Unmarshal(before, &old); err != nil {
+			return nil, err
+		}
+	}
+	if after != nil {
+		if err := json.Unmarshal(after, &updated); err != nil {
+			return nil, err
+		}
+	}
+	
+	null := json.RawMessage("null")
+	diff := make(map[string][2]json.RawMessage)
+	for field, value := range updated {
+		previous, ok := old[field]
+		if !ok {
+			previous = null
+		}
+		if !bytes.Equal(previous, value) {
+			diff[field] = [2]json.RawMessage{previous, value}
+		}
+	}
+	for field, value := range old {
+		if _, ok := updated[field]; !ok {
+			diff[field] = [2]json.RawMessage{value, null}
+		}
+	}
+	
+	return diff, nil
+}
+
+// ListAuditEvents returns the events recorded at or after since, oldest first
+func (s *BadgerService) ListAuditEvents(since time.Time) ([]AuditEvent, error) {
+	var events []AuditEvent
+	
+	err := s.view(func(txn *badger.Txn) error {
+		it := txn.NewIterator(s.iteratorOptions())
+		defer it.Close()
+		
+		prefix := s.scopedKey(s.joinKey("audit", ""))
+		for it.Seek(s.auditKey(since, 0)); it.ValidForPrefix(prefix); it.Next() {
+			var event AuditEvent
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &event)
+			})
+			if err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	return events, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAuditEventsForCreateAndUpdate(t *testing.T) {
+	s := newTestService(t)
+	
+	// Both writes get the same timestamp, only the sequence orders them
+	later := testClock.Add(time.Hour)
+	s.SetClock(func() time.Time { return later })
+	
+	user := &User{Name: "Dana", Email: "dana@techcorp.com", CompanyID: 1}
+	if err := s.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	user.Name = "Dana White"
+	if err := s.Users().Update(user); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	
+	events, err := s.ListAuditEvents(later)
+	if err != nil {
+		t.Fatalf("ListAuditEvents: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("got %d events since %v, want 2", len(events), later)
+	}
+	
+	created, updated := events[0], events[1]
+	if created.Op != "create" || created.Entity != "users" || created.EntityID != user.ID {
+		t.Errorf("first event is %s %s %d, want create users %d", created.Op, created.Entity, created.EntityID, user.ID)
+	}
+	if updated.Op != "update" || updated.EntityID != user.ID || !updated.Timestamp.Equal(later) {
+		t.Errorf("second event is %s %d at %v, want update %d at %v", updated.Op, updated.EntityID, updated.Timestamp, user.ID, later)
+	}
+	
+	change, ok := updated.Diff["name"]
+	if !ok || len(updated.Diff) != 1 {
+		t.Fatalf("update diff is %v, want only the name", updated.Diff)
+	}
+	var before, after string
+	if err := json.Unmarshal(change[0], &before); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(change[1], &after); err != nil {
+		t.Fatal(err)
+	}
+	if before != "Dana" || after != "Dana White" {
+		t.Errorf("name changed from %q to %q, want Dana to Dana White", before, after)
+	}
+	
+	// The seed data was written before
+	all, err := s.ListAuditEvents(time.Time{})
+	if err != nil {
+		t.Fatalf("ListAuditEvents: %v", err)
+	}
+	if len(all) != 16+2 {
+		t.Errorf("got %d events in total, want the 16 seed creates and 2 more", len(all))
+	}
+}
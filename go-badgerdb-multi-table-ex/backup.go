@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io"
+)
+
+// maxPendingWrites bounds the number of in-flight writes while loading a backup
+const maxPendingWrites = 256
+
+// Backup dumps every key written after version since to w. Pass 0 for a full
+// backup; the returned version can be passed as since on the next call to
+// take an incremental backup.
+func (s *BadgerService) Backup(w io.Writer, since uint64) (uint64, error) {
+	if err := s.checkOpen(); err != nil {
+		return 0, err
+	}
+	return s.db.Backup(w, since)
+}
+
+// Load restores a backup produced by Backup. Counters are reloaded afterwards
+// so new IDs continue from the restored data.
+func (s *BadgerService) Load(r io.Reader) error {
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	
+	if err := s.db.Load(r, maxPendingWrites); err != nil {
+		return err
+	}
+	
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resetStatsCache()
+	s.resetExistence()
+	if err := s.initCounters(); err != nil {
+		return err
+	}
+	
+	return s.recountAllLive()
+}
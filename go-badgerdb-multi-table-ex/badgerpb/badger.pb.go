@@ -0,0 +1,896 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: badger.proto
+
+package badgerpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EntityID struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EntityID) Reset() {
+	*x = EntityID{}
+	mi := &file_badger_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EntityID) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EntityID) ProtoMessage() {}
+
+func (x *EntityID) ProtoReflect() protoreflect.Message {
+	mi := &file_badger_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EntityID.ProtoReflect.Descriptor instead.
+func (*EntityID) Descriptor() ([]byte, []int) {
+	return file_badger_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EntityID) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type User struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	CompanyId     int64                  `protobuf:"varint,4,opt,name=company_id,json=companyId,proto3" json:"company_id,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_badger_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_badger_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_badger_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *User) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *User) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *User) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *User) GetCompanyId() int64 {
+	if x != nil {
+		return x.CompanyId
+	}
+	return 0
+}
+
+func (x *User) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type Company struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Industry      string                 `protobuf:"bytes,3,opt,name=industry,proto3" json:"industry,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Company) Reset() {
+	*x = Company{}
+	mi := &file_badger_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Company) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Company) ProtoMessage() {}
+
+func (x *Company) ProtoReflect() protoreflect.Message {
+	mi := &file_badger_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Company.ProtoReflect.Descriptor instead.
+func (*Company) Descriptor() ([]byte, []int) {
+	return file_badger_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Company) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Company) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Company) GetIndustry() string {
+	if x != nil {
+		return x.Industry
+	}
+	return ""
+}
+
+func (x *Company) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type Order struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ProductId     int64                  `protobuf:"varint,3,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Quantity      int64                  `protobuf:"varint,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Amount        float64                `protobuf:"fixed64,5,opt,name=amount,proto3" json:"amount,omitempty"`
+	Status        string                 `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	LineItems     []*LineItem            `protobuf:"bytes,8,rep,name=line_items,json=lineItems,proto3" json:"line_items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Order) Reset() {
+	*x = Order{}
+	mi := &file_badger_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Order) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Order) ProtoMessage() {}
+
+func (x *Order) ProtoReflect() protoreflect.Message {
+	mi := &file_badger_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Order.ProtoReflect.Descriptor instead.
+func (*Order) Descriptor() ([]byte, []int) {
+	return file_badger_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Order) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Order) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Order) GetProductId() int64 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *Order) GetQuantity() int64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *Order) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Order) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Order) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Order) GetLineItems() []*LineItem {
+	if x != nil {
+		return x.LineItems
+	}
+	return nil
+}
+
+type LineItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     int64                  `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Quantity      int64                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	UnitPrice     float64                `protobuf:"fixed64,3,opt,name=unit_price,json=unitPrice,proto3" json:"unit_price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LineItem) Reset() {
+	*x = LineItem{}
+	mi := &file_badger_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LineItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LineItem) ProtoMessage() {}
+
+func (x *LineItem) ProtoReflect() protoreflect.Message {
+	mi := &file_badger_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LineItem.ProtoReflect.Descriptor instead.
+func (*LineItem) Descriptor() ([]byte, []int) {
+	return file_badger_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LineItem) GetProductId() int64 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *LineItem) GetQuantity() int64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *LineItem) GetUnitPrice() float64 {
+	if x != nil {
+		return x.UnitPrice
+	}
+	return 0
+}
+
+type Product struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Price         float64                `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`
+	CategoryId    int64                  `protobuf:"varint,4,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	CompanyId     int64                  `protobuf:"varint,5,opt,name=company_id,json=companyId,proto3" json:"company_id,omitempty"`
+	Description   string                 `protobuf:"bytes,6,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Product) Reset() {
+	*x = Product{}
+	mi := &file_badger_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Product) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Product) ProtoMessage() {}
+
+func (x *Product) ProtoReflect() protoreflect.Message {
+	mi := &file_badger_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Product.ProtoReflect.Descriptor instead.
+func (*Product) Descriptor() ([]byte, []int) {
+	return file_badger_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Product) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Product) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Product) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Product) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *Product) GetCompanyId() int64 {
+	if x != nil {
+		return x.CompanyId
+	}
+	return 0
+}
+
+func (x *Product) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type Category struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Category) Reset() {
+	*x = Category{}
+	mi := &file_badger_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Category) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Category) ProtoMessage() {}
+
+func (x *Category) ProtoReflect() protoreflect.Message {
+	mi := &file_badger_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Category.ProtoReflect.Descriptor instead.
+func (*Category) Descriptor() ([]byte, []int) {
+	return file_badger_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Category) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Category) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// LineDetails is a line item joined with its product and category
+type LineDetails struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Item          *LineItem              `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	Product       *Product               `protobuf:"bytes,2,opt,name=product,proto3" json:"product,omitempty"`
+	Category      *Category              `protobuf:"bytes,3,opt,name=category,proto3" json:"category,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LineDetails) Reset() {
+	*x = LineDetails{}
+	mi := &file_badger_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LineDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LineDetails) ProtoMessage() {}
+
+func (x *LineDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_badger_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LineDetails.ProtoReflect.Descriptor instead.
+func (*LineDetails) Descriptor() ([]byte, []int) {
+	return file_badger_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *LineDetails) GetItem() *LineItem {
+	if x != nil {
+		return x.Item
+	}
+	return nil
+}
+
+func (x *LineDetails) GetProduct() *Product {
+	if x != nil {
+		return x.Product
+	}
+	return nil
+}
+
+func (x *LineDetails) GetCategory() *Category {
+	if x != nil {
+		return x.Category
+	}
+	return nil
+}
+
+// OrderWithDetails carries every line of the order in lines. product and
+// category are those of the first line, for clients that predate lines.
+type OrderWithDetails struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	User          *User                  `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Product       *Product               `protobuf:"bytes,3,opt,name=product,proto3" json:"product,omitempty"`
+	Category      *Category              `protobuf:"bytes,4,opt,name=category,proto3" json:"category,omitempty"`
+	Lines         []*LineDetails         `protobuf:"bytes,5,rep,name=lines,proto3" json:"lines,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderWithDetails) Reset() {
+	*x = OrderWithDetails{}
+	mi := &file_badger_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderWithDetails) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderWithDetails) ProtoMessage() {}
+
+func (x *OrderWithDetails) ProtoReflect() protoreflect.Message {
+	mi := &file_badger_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderWithDetails.ProtoReflect.Descriptor instead.
+func (*OrderWithDetails) Descriptor() ([]byte, []int) {
+	return file_badger_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *OrderWithDetails) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *OrderWithDetails) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *OrderWithDetails) GetProduct() *Product {
+	if x != nil {
+		return x.Product
+	}
+	return nil
+}
+
+func (x *OrderWithDetails) GetCategory() *Category {
+	if x != nil {
+		return x.Category
+	}
+	return nil
+}
+
+func (x *OrderWithDetails) GetLines() []*LineDetails {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+type GetOrdersWithDetailsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Orders        []*OrderWithDetails    `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrdersWithDetailsResponse) Reset() {
+	*x = GetOrdersWithDetailsResponse{}
+	mi := &file_badger_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrdersWithDetailsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrdersWithDetailsResponse) ProtoMessage() {}
+
+func (x *GetOrdersWithDetailsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_badger_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrdersWithDetailsResponse.ProtoReflect.Descriptor instead.
+func (*GetOrdersWithDetailsResponse) Descriptor() ([]byte, []int) {
+	return file_badger_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetOrdersWithDetailsResponse) GetOrders() []*OrderWithDetails {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+var File_badger_proto protoreflect.FileDescriptor
+
+const file_badger_proto_rawDesc = "" +
+	"\n" +
+	"\fbadger.proto\x12\tbadger.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x1a\n" +
+	"\bEntityID\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\x9a\x01\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\x1d\n" +
+	"\n" +
+	"company_id\x18\x04 \x01(\x03R\tcompanyId\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x84\x01\n" +
+	"\aCompany\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1a\n" +
+	"\bindustry\x18\x03 \x01(\tR\bindustry\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x8a\x02\n" +
+	"\x05Order\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x03 \x01(\x03R\tproductId\x12\x1a\n" +
+	"\bquantity\x18\x04 \x01(\x03R\bquantity\x12\x16\n" +
+	"\x06amount\x18\x05 \x01(\x01R\x06amount\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x122\n" +
+	"\n" +
+	"line_items\x18\b \x03(\v2\x13.badger.v1.LineItemR\tlineItems\"d\n" +
+	"\bLineItem\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\x03R\tproductId\x12\x1a\n" +
+	"\bquantity\x18\x02 \x01(\x03R\bquantity\x12\x1d\n" +
+	"\n" +
+	"unit_price\x18\x03 \x01(\x01R\tunitPrice\"\xa5\x01\n" +
+	"\aProduct\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\x01R\x05price\x12\x1f\n" +
+	"\vcategory_id\x18\x04 \x01(\x03R\n" +
+	"categoryId\x12\x1d\n" +
+	"\n" +
+	"company_id\x18\x05 \x01(\x03R\tcompanyId\x12 \n" +
+	"\vdescription\x18\x06 \x01(\tR\vdescription\".\n" +
+	"\bCategory\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"\x95\x01\n" +
+	"\vLineDetails\x12'\n" +
+	"\x04item\x18\x01 \x01(\v2\x13.badger.v1.LineItemR\x04item\x12,\n" +
+	"\aproduct\x18\x02 \x01(\v2\x12.badger.v1.ProductR\aproduct\x12/\n" +
+	"\bcategory\x18\x03 \x01(\v2\x13.badger.v1.CategoryR\bcategory\"\xec\x01\n" +
+	"\x10OrderWithDetails\x12&\n" +
+	"\x05order\x18\x01 \x01(\v2\x10.badger.v1.OrderR\x05order\x12#\n" +
+	"\x04user\x18\x02 \x01(\v2\x0f.badger.v1.UserR\x04user\x12,\n" +
+	"\aproduct\x18\x03 \x01(\v2\x12.badger.v1.ProductR\aproduct\x12/\n" +
+	"\bcategory\x18\x04 \x01(\v2\x13.badger.v1.CategoryR\bcategory\x12,\n" +
+	"\x05lines\x18\x05 \x03(\v2\x16.badger.v1.LineDetailsR\x05lines\"S\n" +
+	"\x1cGetOrdersWithDetailsResponse\x123\n" +
+	"\x06orders\x18\x01 \x03(\v2\x1b.badger.v1.OrderWithDetailsR\x06orders2\xc7\t\n" +
+	"\rBadgerService\x12.\n" +
+	"\n" +
+	"CreateUser\x12\x0f.badger.v1.User\x1a\x0f.badger.v1.User\x12/\n" +
+	"\aGetUser\x12\x13.badger.v1.EntityID\x1a\x0f.badger.v1.User\x12.\n" +
+	"\n" +
+	"UpdateUser\x12\x0f.badger.v1.User\x1a\x0f.badger.v1.User\x129\n" +
+	"\n" +
+	"DeleteUser\x12\x13.badger.v1.EntityID\x1a\x16.google.protobuf.Empty\x127\n" +
+	"\rCreateCompany\x12\x12.badger.v1.Company\x1a\x12.badger.v1.Company\x125\n" +
+	"\n" +
+	"GetCompany\x12\x13.badger.v1.EntityID\x1a\x12.badger.v1.Company\x127\n" +
+	"\rUpdateCompany\x12\x12.badger.v1.Company\x1a\x12.badger.v1.Company\x12<\n" +
+	"\rDeleteCompany\x12\x13.badger.v1.EntityID\x1a\x16.google.protobuf.Empty\x121\n" +
+	"\vCreateOrder\x12\x10.badger.v1.Order\x1a\x10.badger.v1.Order\x121\n" +
+	"\bGetOrder\x12\x13.badger.v1.EntityID\x1a\x10.badger.v1.Order\x121\n" +
+	"\vUpdateOrder\x12\x10.badger.v1.Order\x1a\x10.badger.v1.Order\x12:\n" +
+	"\vDeleteOrder\x12\x13.badger.v1.EntityID\x1a\x16.google.protobuf.Empty\x127\n" +
+	"\rCreateProduct\x12\x12.badger.v1.Product\x1a\x12.badger.v1.Product\x125\n" +
+	"\n" +
+	"GetProduct\x12\x13.badger.v1.EntityID\x1a\x12.badger.v1.Product\x127\n" +
+	"\rUpdateProduct\x12\x12.badger.v1.Product\x1a\x12.badger.v1.Product\x12<\n" +
+	"\rDeleteProduct\x12\x13.badger.v1.EntityID\x1a\x16.google.protobuf.Empty\x12:\n" +
+	"\x0eCreateCategory\x12\x13.badger.v1.Category\x1a\x13.badger.v1.Category\x127\n" +
+	"\vGetCategory\x12\x13.badger.v1.EntityID\x1a\x13.badger.v1.Category\x12:\n" +
+	"\x0eUpdateCategory\x12\x13.badger.v1.Category\x1a\x13.badger.v1.Category\x12=\n" +
+	"\x0eDeleteCategory\x12\x13.badger.v1.EntityID\x1a\x16.google.protobuf.Empty\x12W\n" +
+	"\x14GetOrdersWithDetails\x12\x16.google.protobuf.Empty\x1a'.badger.v1.GetOrdersWithDetailsResponseB\x18Z\x16go-baderdb-ex/badgerpbb\x06proto3"
+
+var (
+	file_badger_proto_rawDescOnce sync.Once
+	file_badger_proto_rawDescData []byte
+)
+
+func file_badger_proto_rawDescGZIP() []byte {
+	file_badger_proto_rawDescOnce.Do(func() {
+		file_badger_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_badger_proto_rawDesc), len(file_badger_proto_rawDesc)))
+	})
+	return file_badger_proto_rawDescData
+}
+
+var file_badger_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_badger_proto_goTypes = []any{
+	(*EntityID)(nil),                     // 0: badger.v1.EntityID
+	(*User)(nil),                         // 1: badger.v1.User
+	(*Company)(nil),                      // 2: badger.v1.Company
+	(*Order)(nil),                        // 3: badger.v1.Order
+	(*LineItem)(nil),                     // 4: badger.v1.LineItem
+	(*Product)(nil),                      // 5: badger.v1.Product
+	(*Category)(nil),                     // 6: badger.v1.Category
+	(*LineDetails)(nil),                  // 7: badger.v1.LineDetails
+	(*OrderWithDetails)(nil),             // 8: badger.v1.OrderWithDetails
+	(*GetOrdersWithDetailsResponse)(nil), // 9: badger.v1.GetOrdersWithDetailsResponse
+	(*timestamppb.Timestamp)(nil),        // 10: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),                // 11: google.protobuf.Empty
+}
+var file_badger_proto_depIdxs = []int32{
+	10, // 0: badger.v1.User.created_at:type_name -> google.protobuf.Timestamp
+	10, // 1: badger.v1.Company.created_at:type_name -> google.protobuf.Timestamp
+	10, // 2: badger.v1.Order.created_at:type_name -> google.protobuf.Timestamp
+	4,  // 3: badger.v1.Order.line_items:type_name -> badger.v1.LineItem
+	4,  // 4: badger.v1.LineDetails.item:type_name -> badger.v1.LineItem
+	5,  // 5: badger.v1.LineDetails.product:type_name -> badger.v1.Product
+	6,  // 6: badger.v1.LineDetails.category:type_name -> badger.v1.Category
+	3,  // 7: badger.v1.OrderWithDetails.order:type_name -> badger.v1.Order
+	1,  // 8: badger.v1.OrderWithDetails.user:type_name -> badger.v1.User
+	5,  // 9: badger.v1.OrderWithDetails.product:type_name -> badger.v1.Product
+	6,  // 10: badger.v1.OrderWithDetails.category:type_name -> badger.v1.Category
+	7,  // 11: badger.v1.OrderWithDetails.lines:type_name -> badger.v1.LineDetails
+	8,  // 12: badger.v1.GetOrdersWithDetailsResponse.orders:type_name -> badger.v1.OrderWithDetails
+	1,  // 13: badger.v1.BadgerService.CreateUser:input_type -> badger.v1.User
+	0,  // 14: badger.v1.BadgerService.GetUser:input_type -> badger.v1.EntityID
+	1,  // 15: badger.v1.BadgerService.UpdateUser:input_type -> badger.v1.User
+	0,  // 16: badger.v1.BadgerService.DeleteUser:input_type -> badger.v1.EntityID
+	2,  // 17: badger.v1.BadgerService.CreateCompany:input_type -> badger.v1.Company
+	0,  // 18: badger.v1.BadgerService.GetCompany:input_type -> badger.v1.EntityID
+	2,  // 19: badger.v1.BadgerService.UpdateCompany:input_type -> badger.v1.Company
+	0,  // 20: badger.v1.BadgerService.DeleteCompany:input_type -> badger.v1.EntityID
+	3,  // 21: badger.v1.BadgerService.CreateOrder:input_type -> badger.v1.Order
+	0,  // 22: badger.v1.BadgerService.GetOrder:input_type -> badger.v1.EntityID
+	3,  // 23: badger.v1.BadgerService.UpdateOrder:input_type -> badger.v1.Order
+	0,  // 24: badger.v1.BadgerService.DeleteOrder:input_type -> badger.v1.EntityID
+	5,  // 25: badger.v1.BadgerService.CreateProduct:input_type -> badger.v1.Product
+	0,  // 26: badger.v1.BadgerService.GetProduct:input_type -> badger.v1.EntityID
+	5,  // 27: badger.v1.BadgerService.UpdateProduct:input_type -> badger.v1.Product
+	0,  // 28: badger.v1.BadgerService.DeleteProduct:input_type -> badger.v1.EntityID
+	6,  // 29: badger.v1.BadgerService.CreateCategory:input_type -> badger.v1.Category
+	0,  // 30: badger.v1.BadgerService.GetCategory:input_type -> badger.v1.EntityID
+	6,  // 31: badger.v1.BadgerService.UpdateCategory:input_type -> badger.v1.Category
+	0,  // 32: badger.v1.BadgerService.DeleteCategory:input_type -> badger.v1.EntityID
+	11, // 33: badger.v1.BadgerService.GetOrdersWithDetails:input_type -> google.protobuf.Empty
+	1,  // 34: badger.v1.BadgerService.CreateUser:output_type -> badger.v1.User
+	1,  // 35: badger.v1.BadgerService.GetUser:output_type -> badger.v1.User
+	1,  // 36: badger.v1.BadgerService.UpdateUser:output_type -> badger.v1.User
+	11, // 37: badger.v1.BadgerService.DeleteUser:output_type -> google.protobuf.Empty
+	2,  // 38: badger.v1.BadgerService.CreateCompany:output_type -> badger.v1.Company
+	2,  // 39: badger.v1.BadgerService.GetCompany:output_type -> badger.v1.Company
+	2,  // 40: badger.v1.BadgerService.UpdateCompany:output_type -> badger.v1.Company
+	11, // 41: badger.v1.BadgerService.DeleteCompany:output_type -> google.protobuf.Empty
+	3,  // 42: badger.v1.BadgerService.CreateOrder:output_type -> badger.v1.Order
+	3,  // 43: badger.v1.BadgerService.GetOrder:output_type -> badger.v1.Order
+	3,  // 44: badger.v1.BadgerService.UpdateOrder:output_type -> badger.v1.Order
+	11, // 45: badger.v1.BadgerService.DeleteOrder:output_type -> google.protobuf.Empty
+	5,  // 46: badger.v1.BadgerService.CreateProduct:output_type -> badger.v1.Product
+	5,  // 47: badger.v1.BadgerService.GetProduct:output_type -> badger.v1.Product
+	5,  // 48: badger.v1.BadgerService.UpdateProduct:output_type -> badger.v1.Product
+	11, // 49: badger.v1.BadgerService.DeleteProduct:output_type -> google.protobuf.Empty
+	6,  // 50: badger.v1.BadgerService.CreateCategory:output_type -> badger.v1.Category
+	6,  // 51: badger.v1.BadgerService.GetCategory:output_type -> badger.v1.Category
+	6,  // 52: badger.v1.BadgerService.UpdateCategory:output_type -> badger.v1.Category
+	11, // 53: badger.v1.BadgerService.DeleteCategory:output_type -> google.protobuf.Empty
+	9,  // 54: badger.v1.BadgerService.GetOrdersWithDetails:output_type -> badger.v1.GetOrdersWithDetailsResponse
+	34, // [34:55] is the sub-list for method output_type
+	13, // [13:34] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_badger_proto_init() }
+func file_badger_proto_init() {
+	if File_badger_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_badger_proto_rawDesc), len(file_badger_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_badger_proto_goTypes,
+		DependencyIndexes: file_badger_proto_depIdxs,
+		MessageInfos:      file_badger_proto_msgTypes,
+	}.Build()
+	File_badger_proto = out.File
+	file_badger_proto_goTypes = nil
+	file_badger_proto_depIdxs = nil
+}
@@ -0,0 +1,5 @@
+// Package badgerpb holds the gRPC definition of the multi-table service,
+// generated from badger.proto.
+package badgerpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative badger.proto
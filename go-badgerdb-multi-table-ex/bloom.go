@@ -0,0 +1,192 @@
+package main
+
+import (
+	"hash/fnv"
+	"strconv"
+	"sync"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// With WithExistenceFilter the service keeps an in-memory counting bloom
+// filter of the record IDs of each entity, so ProbablyExists answers without
+// touching the LSM tree. Each key sets bloomHashes one-byte counters; a delete
+// decrements them, except counters that saturated, which stay set.
+
+const (
+	// bloomCountersPerKey and bloomHashes give about 1% false positives at
+	// the expected number of records
+	bloomCountersPerKey = 10
+	bloomHashes         = 7
+)
+
+// bloomFilter is a counting bloom filter over record keys
+type bloomFilter struct {
+	mu       sync.RWMutex
+	counters []uint8
+}
+
+func newBloomFilter(expectedKeys int) *bloomFilter {
+	return &bloomFilter{counters: make([]uint8, max(expectedKeys, 1)*bloomCountersPerKey)}
+}
+
+// positions returns the counters of key, derived from two FNV hashes
+func (f *bloomFilter) positions(key []byte) [bloomHashes]int {
+	h1 := fnv.New64a()
+	h1.Write(key)
+	h2 := fnv.New64()
+	h2.Write(key)
+	a, b := h1.Sum64(), h2.Sum64()|1
+	
+	var pos [bloomHashes]int
+	for i := range pos {
+		pos[i] = int((a + uint64(i)*b) % uint64(len(f.counters)))
+	}
+	return pos
+}
+
+func (f *bloomFilter) add(key []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	
+	for _, p := range f.positions(key) {
+		if f.counters[p] < 255 {
+			f.counters[p]++
+		}
+	}
+}
+
+func (f *bloomFilter) remove(key []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	
+	for _, p := range f.positions(key) {
+		// A saturated counter may stand for more keys than it counts
+		if f.counters[p] > 0 && f.counters[p] < 255 {
+			f.counters[p]--
+		}
+	}
+}
+
+func (f *bloomFilter) mayContain(key []byte) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	
+	for _, p := range f.positions(key) {
+		if f.counters[p] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// existenceFilters holds one filter per tenant, built from the stored records
+// the first time the tenant is used. The root service and its tenant views
+// share it, so all see each other's writes.
+type existenceFilters struct {
+	expectedKeys int
+	mu           sync.Mutex
+	byTenant     map[string]*bloomFilter
+}
+
+// existenceFilter returns the filter of the service's tenant, building it if
+// needed. It returns nil if existence filtering is off.
+func (s *BadgerService) existenceFilter() (*bloomFilter, error) {
+	if s.existence == nil {
+		return nil, nil
+	}
+	
+	s.existence.mu.Lock()
+	defer s.existence.mu.Unlock()
+	
+	if f := s.existence.byTenant[s.tenant]; f != nil {
+		return f, nil
+	}
+	
+	f := newBloomFilter(s.existence.expectedKeys)
+	err := s.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false // Only need keys
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		
+		for entity := range entityTypes {
+			prefix := s.entityPrefix(entity)
+			for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+				key := it.Item().Key()
+				if _, err := strconv.ParseInt(string(key[len(prefix):]), 10, 64); err == nil {
+					f.add(key)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	s.existence.byTenant[s.tenant] = f
+	return f, nil
+}
+
+// markExists adds record id of entity to the filter. It may run before the
+// write commits: if the write is lost, the record is only a false positive.
+func (s *BadgerService) markExists(entity string, id int64) {
+	if f, err := s.existenceFilter(); f != nil && err == nil {
+		f.add(s.recordKey(entity, id))
+	}
+}
+
+// markDeleted removes record id of entity from the filter. It must only run
+// once the delete committed, or the record would be missed.
+func (s *BadgerService) markDeleted(entity string, id int64) {
+	if f, err := s.existenceFilter(); f != nil && err == nil {
+		f.remove(s.recordKey(entity, id))
+	}
+}
+
+// resetExistence drops the filters of every tenant after a bulk write, so
+// they are rebuilt from the stored records
+func (s *BadgerService) resetExistence() {
+	if s.existence == nil {
+		return
+	}
+	
+	s.existence.mu.Lock()
+	defer s.existence.mu.Unlock()
+	clear(s.existence.byTenant)
+}
+
+// ProbablyExists reports whether record id of entity may exist without
+// reading the database. false is certain; true may be a false positive, about
+// 1% of the time at the expected number of records and more beyond it, and
+// should be confirmed with Exists. Records deleted inside WithTransaction or
+// by a cascade stay in the filter as false positives, while DeleteWhere and
+// DeletePrefix rebuild it. Without WithExistenceFilter, or if the filter cannot be built, it
+// always returns true.
+func (s *BadgerService) ProbablyExists(entity string, id int64) bool {
+	f, err := s.existenceFilter()
+	if f == nil || err != nil {
+		return true
+	}
+	return f.mayContain(s.recordKey(entity, id))
+}
+
+// Exists reports whether record id of entity is stored. With
+// WithExistenceFilter most missing records are ruled out in memory.
+func (s *BadgerService) Exists(entity string, id int64) (bool, error) {
+	if !s.ProbablyExists(entity, id) {
+		return false, nil
+	}
+	
+	var exists bool
+	err := s.view(func(txn *badger.Txn) error {
+		_, err := txn.Get(s.recordKey(entity, id))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		exists = err == nil
+		return err
+	})
+	return exists, err
+}
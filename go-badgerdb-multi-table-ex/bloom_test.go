@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestExistenceFilterNoFalseNegatives(t *testing.T) {
+	// A small filter makes records share counters, so a stray removal shows
+	s := newTestService(t, WithExistenceFilter(8))
+	
+	var stored []int64
+	for i := 0; i < 20; i++ {
+		product := &Product{Name: "Widget", Price: 1}
+		if err := s.CreateProduct(product); err != nil {
+			t.Fatalf("CreateProduct: %v", err)
+		}
+		stored = append(stored, product.ID)
+	}
+	
+	// Delete every other product twice, then ids that were never stored
+	var kept []int64
+	for i, id := range stored {
+		if i%2 == 1 {
+			kept = append(kept, id)
+			continue
+		}
+		for range 2 {
+			if err := s.DeleteProduct(id); err != nil {
+				t.Fatalf("DeleteProduct(%d): %v", id, err)
+			}
+		}
+	}
+	for id := int64(1000); id < 1200; id++ {
+		if err := s.DeleteProduct(id); err != nil {
+			t.Fatalf("DeleteProduct(%d): %v", id, err)
+		}
+	}
+	
+	for _, id := range kept {
+		if !s.ProbablyExists("products", id) {
+			t.Errorf("ProbablyExists(%d) = false for a stored product", id)
+		}
+		exists, err := s.Exists("products", id)
+		if err != nil || !exists {
+			t.Errorf("Exists(%d) = %v, %v, want true", id, exists, err)
+		}
+	}
+	for i := 0; i < len(stored); i += 2 {
+		exists, err := s.Exists("products", stored[i])
+		if err != nil || exists {
+			t.Errorf("Exists(%d) = %v, %v for a deleted product", stored[i], exists, err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// CascadeCounts reports how many records a cascading delete removed
+type CascadeCounts struct {
+	Companies int `json:"companies"`
+	Users     int `json:"users"`
+	Orders    int `json:"orders"`
+}
+
+// DeleteCompanyCascade deletes a company together with its users and their
+// orders, all in one transaction.
+//
+// Every deleted record, its index entries and its audit event count against
+// Badger's transaction size limit. A company with a very large number of
+// users or orders makes the delete fail with badger.ErrTxnTooBig, in which
+// case nothing is removed.
+func (s *BadgerService) DeleteCompanyCascade(id int64) (CascadeCounts, error) {
+	var counts CascadeCounts
+	
+	err := s.writeTxn(func(txn *badger.Txn) error {
+		_, err := txn.Get(s.recordKey("companies", id))
+		if err == badger.ErrKeyNotFound {
+			return notFound("companies", id, err)
+		}
+		if err != nil {
+			return err
+		}
+		
+		userIDs, err := s.companyUserIDs(txn, id)
+		if err != nil {
+			return err
+		}
+		
+		for _, userID := range userIDs {
+			orderIDs, err := s.lookupIndex(txn, "orders", "user", strconv.FormatInt(userID, 10))
+			if err != nil {
+				return err
+			}
+			
+			for _, orderID := range orderIDs {
+				if _, err := s.deleteRecord(txn, "orders", orderID); err != nil {
+					return err
+				}
+				counts.Orders++
+			}
+			
+			if _, err := s.deleteRecord(txn, "users", userID); err != nil {
+				return err
+			}
+			counts.Users++
+		}
+		
+		if _, err := s.deleteRecord(txn, "companies", id); err != nil {
+			return err
+		}
+		counts.Companies++
+		
+		return nil
+	})
+	if err != nil {
+		return CascadeCounts{}, err
+	}
+	
+	s.invalidateStatsFor("companies")
+	return counts, nil
+}
+
+// companyUserIDs returns the IDs of the users working at a company, leaving
+// out soft-deleted ones
+func (s *BadgerService) companyUserIDs(txn *badger.Txn, companyID int64) ([]int64, error) {
+	deleted, err := s.softDeletedIDs(txn, "users")
+	if err != nil {
+		return nil, err
+	}
+	
+	it := txn.NewIterator(s.iteratorOptions())
+	defer it.Close()
+	
+	var ids []int64
+	prefix := s.entityPrefix("users")
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		if isSoftDeletedKey(deleted, it.Item().Key(), prefix) {
+			continue
+		}
+		
+		var user User
+		err := it.Item().Value(func(val []byte) error {
+			return s.codec.Unmarshal(val, &user)
+		})
+		if err != nil {
+			return nil, err
+		}
+		
+		if user.CompanyID == companyID {
+			ids = append(ids, user.ID)
+		}
+	}
+	
+	return ids, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestDeleteCompanyCascade(t *testing.T) {
+	s := newTestService(t)
+	
+	// Tech Corp employs Alice and Charlie, who placed orders 1, 3 and 4
+	counts, err := s.DeleteCompanyCascade(1)
+	if err != nil {
+		t.Fatalf("DeleteCompanyCascade: %v", err)
+	}
+	if want := (CascadeCounts{Companies: 1, Users: 2, Orders: 3}); counts != want {
+		t.Errorf("got counts %+v, want %+v", counts, want)
+	}
+	
+	if _, err := s.Companies().Get(1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("company 1: got %v, want ErrNotFound", err)
+	}
+	for _, id := range []int64{1, 3} {
+		if _, err := s.Users().Get(id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("user %d: got %v, want ErrNotFound", id, err)
+		}
+	}
+	for _, id := range []int64{1, 3, 4} {
+		if _, err := s.Orders().Get(id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("order %d: got %v, want ErrNotFound", id, err)
+		}
+	}
+	
+	// Bob and his order at Fashion Ltd are untouched
+	users, err := s.Users().List()
+	if err != nil || len(users) != 1 || users[0].ID != 2 {
+		t.Errorf("remaining users %+v, %v, want Bob", users, err)
+	}
+	orders, err := s.Orders().List()
+	if err != nil || len(orders) != 1 || orders[0].ID != 2 {
+		t.Errorf("remaining orders %+v, %v, want order 2", orders, err)
+	}
+	
+	if _, err := s.DeleteCompanyCascade(1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting again: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestDeleteCompanyCascadeSkipsSoftDeletedUsers(t *testing.T) {
+	s := newTestService(t)
+	
+	if err := s.SoftDelete("users", 3); err != nil {
+		t.Fatalf("SoftDelete: %v", err)
+	}
+	
+	// Only Alice and her orders 1 and 3 are cascaded through
+	counts, err := s.DeleteCompanyCascade(1)
+	if err != nil {
+		t.Fatalf("DeleteCompanyCascade: %v", err)
+	}
+	if want := (CascadeCounts{Companies: 1, Users: 1, Orders: 2}); counts != want {
+		t.Errorf("got counts %+v, want %+v", counts, want)
+	}
+	
+	// Charlie stays hidden, to be removed by PurgeSoftDeleted
+	err = s.view(func(txn *badger.Txn) error {
+		if _, err := txn.Get(s.recordKey("users", 3)); err != nil {
+			return err
+		}
+		deleted, err := s.isSoftDeleted(txn, "users", 3)
+		if err == nil && !deleted {
+			t.Error("Charlie is no longer soft-deleted")
+		}
+		return err
+	})
+	if err != nil {
+		t.Errorf("reading Charlie: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ErrServiceClosed is returned by every method called after Close. It wraps
+// badger.ErrDBClosed.
+var ErrServiceClosed = fmt.Errorf("badger service is closed: %w", badger.ErrDBClosed)
+
+// checkOpen returns ErrServiceClosed once the service has been closed, so a
+// call racing with shutdown fails cleanly instead of reaching the database
+func (s *BadgerService) checkOpen() error {
+	if s.closed.Load() {
+		return ErrServiceClosed
+	}
+	return nil
+}
+
+// onView, if set, is called each time view opens a transaction. Benchmarks
+// set it to count the transactions a method needs.
+var onView func()
+
+// view runs fn in a read-only transaction, or fails with ErrServiceClosed
+func (s *BadgerService) view(fn func(txn *badger.Txn) error) error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	if onView != nil {
+		onView()
+	}
+	return s.db.View(fn)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMethodsAfterClose(t *testing.T) {
+	s := newTestService(t)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	
+	if _, err := s.Users().List(); !errors.Is(err, ErrServiceClosed) {
+		t.Errorf("List: got %v, want ErrServiceClosed", err)
+	}
+	if err := s.RunValueLogGC(0.5); !errors.Is(err, ErrServiceClosed) {
+		t.Errorf("RunValueLogGC: got %v, want ErrServiceClosed", err)
+	}
+	if _, err := s.GetUsersWithCompaniesConcurrent(4); !errors.Is(err, ErrServiceClosed) {
+		t.Errorf("GetUsersWithCompaniesConcurrent: got %v, want ErrServiceClosed", err)
+	}
+}
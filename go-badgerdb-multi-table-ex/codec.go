@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec encodes the records stored under each key. Keys are the same
+// whatever the codec, only the value encoding changes.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// ErrCodecMismatch is returned when a value is decoded with a codec other
+// than the one it was written with
+var ErrCodecMismatch = errors.New("value was written with a different codec")
+
+// gobTag is the first byte of every gob-encoded value. JSON values are not
+// tagged so existing data stays readable; a JSON object always starts with
+// '{', which can never be mistaken for the tag.
+const gobTag byte = 0x01
+
+// JSONCodec is the default codec
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	if len(data) > 0 && data[0] == gobTag {
+		return fmt.Errorf("%w: found gob, expected JSON", ErrCodecMismatch)
+	}
+	return json.Unmarshal(data, v)
+}
+
+// GobCodec stores records in Go's native gob format. It keeps time.Time and
+// float values exact, but the data can only be read back from Go.
+type GobCodec struct{}
+
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	buf := bytes.NewBuffer([]byte{gobTag})
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+	if len(data) == 0 || data[0] != gobTag {
+		return fmt.Errorf("%w: expected gob", ErrCodecMismatch)
+	}
+	return gob.NewDecoder(bytes.NewReader(data[1:])).Decode(v)
+}
+
+// MsgpackCodec stores records as MessagePack, which is smaller and cheaper to
+// encode than JSON. Field names are taken from the json struct tags.
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (MsgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	if len(data) > 0 && data[0] == gobTag {
+		return fmt.Errorf("%w: found gob, expected MessagePack", ErrCodecMismatch)
+	}
+	
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+// toJSON converts a stored value of entity to JSON, for the consumers that
+// always work on JSON such as validators and the audit log
+func (s *BadgerService) toJSON(entity string, val []byte) ([]byte, error) {
+	if _, ok := s.codec.(JSONCodec); ok {
+		return val, nil
+	}
+	
+	newRecord, ok := entityTypes[entity]
+	if !ok {
+		return nil, fmt.Errorf("no record type registered for %s", entity)
+	}
+	
+	record := newRecord()
+	if err := s.codec.Unmarshal(val, record); err != nil {
+		return nil, err
+	}
+	return json.Marshal(record)
+}
+
+// decodeSlice decodes vals into result, which must point to a []T
+func (s *BadgerService) decodeSlice(vals [][]byte, result interface{}) error {
+	slice := reflect.ValueOf(result).Elem()
+	elemType := slice.Type().Elem()
+	
+	items := reflect.MakeSlice(slice.Type(), 0, len(vals))
+	for _, val := range vals {
+		item := reflect.New(elemType)
+		if err := s.codec.Unmarshal(val, item.Interface()); err != nil {
+			return err
+		}
+		items = reflect.Append(items, item.Elem())
+	}
+	
+	slice.Set(items)
+	return nil
+}
+
+// decodeMap decodes vals into result, which must point to a map[int64]T
+func (s *BadgerService) decodeMap(vals map[int64][]byte, result interface{}) error {
+	m := reflect.ValueOf(result).Elem()
+	elemType := m.Type().Elem()
+	
+	items := reflect.MakeMapWithSize(m.Type(), len(vals))
+	for id, val := range vals {
+		item := reflect.New(elemType)
+		if err := s.codec.Unmarshal(val, item.Interface()); err != nil {
+			return err
+		}
+		items.SetMapIndex(reflect.ValueOf(id), item.Elem())
+	}
+	
+	m.Set(items)
+	return nil
+}
+
+// MigrateCodec re-encodes every record written with from using to, and
+// returns how many records were rewritten. Counters, index entries and audit
+// events have their own formats and are left alone, as are keys of unknown
+// entities. On a tenant view only the tenant's records are migrated. The
+// service uses to from then on. It must not run concurrently with other
+// writes.
+func (s *BadgerService) MigrateCodec(from, to Codec) (int, error) {
+	if err := s.checkWritable(); err != nil {
+		return 0, err
+	}
+	
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+	
+	migrated := 0
+	err := s.view(func(txn *badger.Txn) error {
+		opts := s.iteratorOptions()
+		opts.Prefix = s.scopedKey("")
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			key := string(item.Key())
+			
+			entity, _, found := strings.Cut(key[len(opts.Prefix):], string(s.sep))
+			newRecord, known := entityTypes[entity]
+			if !found || !known {
+				continue // counter:, idx:, audit: and unknown entities
+			}
+			
+			record := newRecord()
+			err := item.Value(func(val []byte) error {
+				return from.Unmarshal(val, record)
+			})
+			if err != nil {
+				return fmt.Errorf("decoding %s: %w", key, err)
+			}
+			
+			val, err := to.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("encoding %s: %w", key, err)
+			}
+			
+			if err := wb.Set(item.KeyCopy(nil), val); err != nil {
+				return err
+			}
+			migrated++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	
+	if err := wb.Flush(); err != nil {
+		return 0, err
+	}
+	
+	s.codec = to
+	return migrated, nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestMsgpackCodecRoundTrip(t *testing.T) {
+	s := newTestService(t, WithCodec(MsgpackCodec{}))
+	
+	user := &User{Name: "Dana White", Email: "dana@techcorp.com", CompanyID: 1}
+	if err := s.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	
+	got, err := s.Users().Get(user.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.ID != user.ID || got.Name != user.Name || got.Email != user.Email || got.CompanyID != 1 || !got.CreatedAt.Equal(testClock) {
+		t.Errorf("got %+v, want %+v", got, user)
+	}
+	
+	// The stored value is MessagePack, not JSON
+	var raw []byte
+	err = s.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(s.recordKey("users", user.ID))
+		if err != nil {
+			return err
+		}
+		raw, err = item.ValueCopy(nil)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("reading the raw value: %v", err)
+	}
+	if len(raw) == 0 || raw[0] == '{' {
+		t.Errorf("stored value %q looks like JSON", raw)
+	}
+	
+	// Joins decode through the codec as well
+	joined, err := s.GetUsersWithCompanies()
+	if err != nil {
+		t.Fatalf("GetUsersWithCompanies: %v", err)
+	}
+	if len(joined) != 4 || joined[3].Company.Name != "Tech Corp" {
+		t.Errorf("got %+v, want Dana joined with Tech Corp", joined)
+	}
+}
+
+func TestGobCodecRoundTrip(t *testing.T) {
+	s := newTestService(t, WithGobCodec())
+	
+	// Timestamps and amounts come back exactly
+	at := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	s.SetClock(func() time.Time { return at })
+	order := &Order{UserID: 1, ProductID: 3, Quantity: 3, Amount: 59.97, Status: "pending"}
+	if err := s.CreateOrder(order); err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	
+	got, err := s.Orders().Get(order.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !got.CreatedAt.Equal(at) || got.Amount != 59.97 || got.Status != "pending" {
+		t.Errorf("got %+v, want %+v", got, order)
+	}
+}
+
+func TestCodecMismatch(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewBadgerService(dir)
+	if err != nil {
+		t.Fatalf("NewBadgerService: %v", err)
+	}
+	if err := s.CreateCategory(&Category{Name: "Books"}); err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	s.Close()
+	
+	s, err = NewBadgerService(dir, WithGobCodec())
+	if err != nil {
+		t.Fatalf("reopening with gob: %v", err)
+	}
+	if _, err := s.Categories().Get(1); !errors.Is(err, ErrCodecMismatch) {
+		t.Errorf("reading JSON with gob: got %v, want ErrCodecMismatch", err)
+	}
+	if err := s.CreateCategory(&Category{Name: "Music"}); err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	s.Close()
+	
+	s, err = NewBadgerService(dir)
+	if err != nil {
+		t.Fatalf("reopening with JSON: %v", err)
+	}
+	defer s.Close()
+	if _, err := s.Categories().Get(2); !errors.Is(err, ErrCodecMismatch) {
+		t.Errorf("reading gob with JSON: got %v, want ErrCodecMismatch", err)
+	}
+}
+
+func TestMigrateCodecToMsgpack(t *testing.T) {
+	s := newTestService(t)
+	
+	n, err := s.MigrateCodec(JSONCodec{}, MsgpackCodec{})
+	if err != nil {
+		t.Fatalf("MigrateCodec: %v", err)
+	}
+	// 3 categories, companies, users and products and 4 orders
+	if n != 16 {
+		t.Errorf("migrated %d records, want 16", n)
+	}
+	
+	users, err := s.Users().List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(users) != 3 || users[0].Name != "Alice Smith" || !users[0].CreatedAt.Equal(testClock) {
+		t.Errorf("got users %+v after the migration", users)
+	}
+	
+	// Every record now decodes as MessagePack and no longer as JSON
+	err = s.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(s.recordKey("orders", 2))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			var order Order
+			if err := (MsgpackCodec{}).Unmarshal(val, &order); err != nil {
+				return err
+			}
+			if order.Amount != 39.98 || order.Quantity != 2 {
+				t.Errorf("order 2 decoded as %+v", order)
+			}
+			if (JSONCodec{}).Unmarshal(val, &order) == nil {
+				t.Error("order 2 still decodes as JSON")
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("reading order 2: %v", err)
+	}
+	
+	// Counters and indexes were left alone
+	if _, err := s.GetUserOrdersWithProducts(1); err != nil {
+		t.Errorf("GetUserOrdersWithProducts: %v", err)
+	}
+	category := &Category{Name: "Garden"}
+	if err := s.CreateCategory(category); err != nil || category.ID != 4 {
+		t.Errorf("CreateCategory: got ID %d, %v, want 4", category.ID, err)
+	}
+}
+
+func BenchmarkCodecMarshal(b *testing.B) {
+	user := &User{ID: 42, Name: "Dana White", Email: "dana@techcorp.com", CompanyID: 7, CreatedAt: time.Now()}
+	
+	for _, c := range []struct {
+		name  string
+		codec Codec
+	}{
+		{"json", JSONCodec{}},
+		{"msgpack", MsgpackCodec{}},
+		{"gob", GobCodec{}},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := c.codec.Marshal(user); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
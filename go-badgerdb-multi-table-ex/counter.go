@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// counterMergeInterval is how often Badger folds the pending increments of a
+// counter into a single value. Tests shorten it.
+var counterMergeInterval = time.Minute
+
+// ID counters are stored as 8-byte big-endian integers under
+// "counter:<entity>", prefixed with the tenant if the service has one.
+// Increments are written as merge entries holding a
+// delta, so allocating an ID never reads the counter back in the same
+// transaction. Older versions of the service stored the counter as a JSON
+// number, which never starts with a zero byte.
+
+func (s *BadgerService) counterKey(entity string) []byte {
+	return s.scopedKey(s.joinKey("counter", entity))
+}
+
+func encodeCounter(n int64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(n))
+	return buf
+}
+
+func decodeCounter(val []byte) int64 {
+	if len(val) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(val))
+}
+
+// isLegacyCounter reports whether val is a counter in the old JSON format
+func isLegacyCounter(val []byte) bool {
+	return len(val) > 0 && val[0] != 0
+}
+
+// addCounters is the merge function of the counter merge operators
+func addCounters(existing, delta []byte) []byte {
+	return encodeCounter(decodeCounter(existing) + decodeCounter(delta))
+}
+
+// ErrCorruptCounter is returned when an ID counter holds neither an 8-byte
+// value nor a legacy JSON number. New IDs could collide with stored records,
+// so the service refuses to open rather than starting the counter over.
+var ErrCorruptCounter = errors.New("corrupt ID counter")
+
+// readCounter sums the versions of a counter key the same way the merge
+// operator does, and also understands a legacy JSON value. legacy reports
+// whether the newest value is in the old format and should be rewritten.
+func readCounter(txn *badger.Txn, key []byte) (counter int64, legacy bool, err error) {
+	opts := badger.DefaultIteratorOptions
+	opts.AllVersions = true
+	it := txn.NewKeyIterator(key, opts)
+	defer it.Close()
+	
+	for it.Rewind(); it.Valid(); it.Next() {
+		item := it.Item()
+		if item.IsDeletedOrExpired() {
+			break
+		}
+		
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return 0, false, err
+		}
+		
+		if isLegacyCounter(val) {
+			var base int64
+			if err := json.Unmarshal(val, &base); err != nil {
+				return 0, false, fmt.Errorf("%w: %s: %v", ErrCorruptCounter, key, err)
+			}
+			// A legacy value was always a full overwrite
+			return counter + base, counter == 0, nil
+		}
+		
+		if len(val) != 8 {
+			return 0, false, fmt.Errorf("%w: %s holds %d bytes", ErrCorruptCounter, key, len(val))
+		}
+		counter += decodeCounter(val)
+		if item.DiscardEarlierVersions() {
+			break
+		}
+	}
+	
+	return counter, false, nil
+}
+
+// maxStoredID returns the highest ID among the records under prefix
+func maxStoredID(txn *badger.Txn, prefix []byte) (int64, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false // Only need keys
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	
+	var maxID int64
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		id, err := strconv.ParseInt(string(it.Item().Key()[len(prefix):]), 10, 64)
+		if err != nil {
+			continue
+		}
+		if id > maxID {
+			maxID = id
+		}
+	}
+	
+	return maxID, nil
+}
+
+// setCounterBase writes counter as the new base value, hiding every earlier
+// version from the merge operator
+func setCounterBase(txn *badger.Txn, key []byte, counter int64) error {
+	return txn.SetEntry(badger.NewEntry(key, encodeCounter(counter)).WithDiscard())
+}
+
+// counterOp returns the merge operator of entity's counter, starting it on
+// first use. mu must be held and the service must be open, so no operator is
+// started after Close has stopped them.
+func (s *BadgerService) counterOp(entity string) *badger.MergeOperator {
+	op, ok := s.counterOps[entity]
+	if !ok {
+		op = s.db.GetMergeOperator(s.counterKey(entity), addCounters, counterMergeInterval)
+		s.counterOps[entity] = op
+	}
+	return op
+}
+
+// stopCounterOps stops the merge operators, folding pending increments one
+// last time
+func (s *BadgerService) stopCounterOps() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	stopMergeOps(s.counterOps)
+}
+
+// stopAllCounterOps stops the counter merge operators of the service and of
+// every tenant a view was opened for. A merge operator left running would
+// fold its counter against the closed database and panic.
+func (s *BadgerService) stopAllCounterOps() {
+	s.stopCounterOps()
+	
+	s.tenants.mu.Lock()
+	defer s.tenants.mu.Unlock()
+	
+	for _, state := range s.tenants.byTenant {
+		state.mu.Lock()
+		stopMergeOps(state.counterOps)
+		state.mu.Unlock()
+	}
+}
+
+// stopMergeOps stops and removes every merge operator in ops
+func stopMergeOps(ops map[string]*badger.MergeOperator) {
+	for entity, op := range ops {
+		op.Stop()
+		delete(ops, entity)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestConcurrentCreatesGetContiguousIDs(t *testing.T) {
+	s := newTestService(t)
+	
+	const workers, perWorker = 8, 25
+	var (
+		mu  sync.Mutex
+		ids []int64
+		wg  sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				category := &Category{Name: "Concurrent"}
+				if err := s.CreateCategory(category); err != nil {
+					t.Errorf("CreateCategory: %v", err)
+					return
+				}
+				mu.Lock()
+				ids = append(ids, category.ID)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	
+	// The seed data holds 3 categories
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for i, id := range ids {
+		if want := int64(i + 4); id != want {
+			t.Fatalf("IDs %v are not unique and contiguous from 4", ids)
+		}
+	}
+	if len(ids) != workers*perWorker {
+		t.Errorf("got %d IDs, want %d", len(ids), workers*perWorker)
+	}
+}
+
+func TestNextIDFailsWhenTheCounterCannotBeUpdated(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewBadgerService(dir)
+	if err != nil {
+		t.Fatalf("NewBadgerService: %v", err)
+	}
+	if err := s.CreateCategory(&Category{Name: "Books"}); err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	s.Close()
+	
+	// A read-only database rejects the counter increment
+	s, err = NewBadgerService(dir, WithReadOnly(true))
+	if err != nil {
+		t.Fatalf("reopening read-only: %v", err)
+	}
+	defer s.Close()
+	
+	_, err = SequentialIDGenerator{}.NextID(s, "categories")
+	if !errors.Is(err, ErrIDAllocation) {
+		t.Errorf("NextID: got %v, want ErrIDAllocation", err)
+	}
+	if got := s.counters["categories"]; got != 1 {
+		t.Errorf("in-memory counter drifted to %d, want 1", got)
+	}
+}
+
+func TestCounterReconciledAfterLosingItsKey(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewBadgerService(dir)
+	if err != nil {
+		t.Fatalf("NewBadgerService: %v", err)
+	}
+	if err := setupTestData(s, false); err != nil {
+		t.Fatalf("setupTestData: %v", err)
+	}
+	s.Close()
+	
+	// Drop the users counter behind the service's back, as a partial
+	// restore would
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	if err != nil {
+		t.Fatalf("badger.Open: %v", err)
+	}
+	err = db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte("counter:users"))
+	})
+	db.Close()
+	if err != nil {
+		t.Fatalf("deleting the counter: %v", err)
+	}
+	
+	s, err = NewBadgerService(dir)
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	defer s.Close()
+	
+	user := &User{Name: "Dana", Email: "dana@techcorp.com", CompanyID: 1}
+	if err := s.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if user.ID != 4 {
+		t.Errorf("got ID %d, want 4 after the 3 stored users", user.ID)
+	}
+	users, err := s.Users().List()
+	if err != nil || len(users) != 4 {
+		t.Errorf("List: got %d users, %v, want 4", len(users), err)
+	}
+}
+
+func TestCorruptCounterFailsOpen(t *testing.T) {
+	for _, value := range []string{"not a number", "\x00\x01\x02"} {
+		dir := t.TempDir()
+		s, err := NewBadgerService(dir)
+		if err != nil {
+			t.Fatalf("NewBadgerService: %v", err)
+		}
+		if err := setupTestData(s, false); err != nil {
+			t.Fatalf("setupTestData: %v", err)
+		}
+		s.Close()
+		
+		db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+		if err != nil {
+			t.Fatalf("badger.Open: %v", err)
+		}
+		err = db.Update(func(txn *badger.Txn) error {
+			return txn.SetEntry(badger.NewEntry([]byte("counter:orders"), []byte(value)).WithDiscard())
+		})
+		db.Close()
+		if err != nil {
+			t.Fatalf("writing the counter: %v", err)
+		}
+		
+		// Starting the counter over would hand out the IDs of stored orders
+		s, err = NewBadgerService(dir)
+		if err == nil {
+			s.Close()
+			t.Errorf("counter %q: the database opened", value)
+			continue
+		}
+		if !errors.Is(err, ErrCorruptCounter) {
+			t.Errorf("counter %q: open failed with %v, want ErrCorruptCounter", value, err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Dashboard combines the figures the dashboard shows
+type Dashboard struct {
+	CompanyStats          []CompanyStats  `json:"company_stats"`
+	TopProductsByCategory []CategorySales `json:"top_products_by_category"`
+	Users                 []User          `json:"users"`
+}
+
+// DashboardData runs GetCompanyStats, GetTopSellingProductsByCategory with
+// reportTopProducts and Users().List concurrently. Unlike GenerateReport each
+// read sees its own snapshot. If ctx is cancelled it returns ctx.Err() without
+// waiting for reads already running, which finish in the background.
+func (s *BadgerService) DashboardData(ctx context.Context) (*Dashboard, error) {
+	var d Dashboard
+	g, gctx := errgroup.WithContext(ctx)
+	
+	g.Go(func() error {
+		if err := gctx.Err(); err != nil {
+			return err
+		}
+		stats, err := s.GetCompanyStats()
+		d.CompanyStats = stats
+		return err
+	})
+	g.Go(func() error {
+		if err := gctx.Err(); err != nil {
+			return err
+		}
+		top, err := s.GetTopSellingProductsByCategory(reportTopProducts)
+		d.TopProductsByCategory = top
+		return err
+	})
+	g.Go(func() error {
+		if err := gctx.Err(); err != nil {
+			return err
+		}
+		users, err := s.Users().List()
+		d.Users = users
+		return err
+	})
+	
+	done := make(chan error, 1)
+	go func() {
+		done <- g.Wait()
+	}()
+	
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, err
+		}
+		return &d, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDashboardDataMatchesSequentialReads(t *testing.T) {
+	s := newTestService(t)
+	
+	got, err := s.DashboardData(context.Background())
+	if err != nil {
+		t.Fatalf("DashboardData: %v", err)
+	}
+	
+	var want Dashboard
+	if want.CompanyStats, err = s.GetCompanyStats(); err != nil {
+		t.Fatalf("GetCompanyStats: %v", err)
+	}
+	if want.TopProductsByCategory, err = s.GetTopSellingProductsByCategory(reportTopProducts); err != nil {
+		t.Fatalf("GetTopSellingProductsByCategory: %v", err)
+	}
+	if want.Users, err = s.Users().List(); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("DashboardData = %+v, want %+v", *got, want)
+	}
+}
+
+func TestDashboardDataCancellation(t *testing.T) {
+	s := newTestService(t)
+	
+	// Hold every read at the start of its transaction until released
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	onView = func() {
+		entered <- struct{}{}
+		<-release
+	}
+	defer func() { onView = nil }()
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		_, err := s.DashboardData(ctx)
+		result <- err
+	}()
+	
+	// One read each for the stats, the top products and the users
+	for i := 0; i < 3; i++ {
+		<-entered
+	}
+	cancel()
+	
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("DashboardData = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("DashboardData did not return after the context was cancelled")
+	}
+	
+	// Closing first makes the held reads fail once released instead of
+	// running on, so none of them reads onView again
+	s.Close()
+	close(release)
+}
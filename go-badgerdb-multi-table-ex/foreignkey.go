@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ErrForeignKeyViolation is returned, when foreign key checks are enabled,
+// for a record that references a record which does not exist
+var ErrForeignKeyViolation = errors.New("foreign key violation")
+
+// foreignKey maps a record to the IDs of the records of entity it references
+type foreignKey struct {
+	field  string
+	entity string
+	values func(record interface{}) []int64
+}
+
+// entityForeignKeys lists the references checked for each entity.
+// Records are always passed as pointers to their struct type.
+var entityForeignKeys = map[string][]foreignKey{
+	"orders": {
+		{field: "user_id", entity: "users", values: func(record interface{}) []int64 {
+			return []int64{record.(*Order).UserID}
+		}},
+		{field: "product_id", entity: "products", values: func(record interface{}) []int64 {
+			var ids []int64
+			for _, item := range record.(*Order).Lines() {
+				ids = append(ids, item.ProductID)
+			}
+			return ids
+		}},
+	},
+}
+
+// checkForeignKeys verifies, within txn, that every record referenced by
+// record exists
+func (s *BadgerService) checkForeignKeys(txn *badger.Txn, entity string, record interface{}) error {
+	for _, fk := range entityForeignKeys[entity] {
+		for _, id := range fk.values(record) {
+			_, err := txn.Get(s.recordKey(fk.entity, id))
+			if err == badger.ErrKeyNotFound {
+				return fmt.Errorf("%w: %s.%s references missing %s %d", ErrForeignKeyViolation, entity, fk.field, fk.entity, id)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FindOrphanedOrders returns the IDs of orders whose user or product no
+// longer exists, e.g. after a delete that was not cascaded. Like get and list
+// it treats soft-deleted records as gone: soft-deleted orders are not
+// reported, and an order of a soft-deleted user or product is.
+func (s *BadgerService) FindOrphanedOrders() ([]int64, error) {
+	var orphans []int64
+	
+	err := s.view(func(txn *badger.Txn) error {
+		// Build the ID sets of the referenced entities first
+		existing := make(map[string]map[int64]bool)
+		for _, fk := range entityForeignKeys["orders"] {
+			if _, ok := existing[fk.entity]; ok {
+				continue
+			}
+			ids, err := s.liveIDs(txn, fk.entity)
+			if err != nil {
+				return err
+			}
+			existing[fk.entity] = ids
+		}
+		
+		deleted, err := s.softDeletedIDs(txn, "orders")
+		if err != nil {
+			return err
+		}
+		
+		it := txn.NewIterator(s.iteratorOptions())
+		defer it.Close()
+		
+		prefix := s.entityPrefix("orders")
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			if isSoftDeletedKey(deleted, it.Item().Key(), prefix) {
+				continue
+			}
+			
+			var order Order
+			err := it.Item().Value(func(val []byte) error {
+				return s.codec.Unmarshal(val, &order)
+			})
+			if err != nil {
+				return err
+			}
+			
+			if referencesMissing(&order, existing) {
+				orphans = append(orphans, order.ID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i] < orphans[j] })
+	return orphans, nil
+}
+
+// referencesMissing reports whether order references an ID absent from the
+// ID set of the referenced entity
+func referencesMissing(order *Order, existing map[string]map[int64]bool) bool {
+	for _, fk := range entityForeignKeys["orders"] {
+		for _, id := range fk.values(order) {
+			if !existing[fk.entity][id] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// storedIDs returns the set of IDs of the records under prefix
+func storedIDs(txn *badger.Txn, prefix []byte) (map[int64]bool, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false // Only need keys
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	
+	ids := make(map[int64]bool)
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		id, err := strconv.ParseInt(string(it.Item().Key()[len(prefix):]), 10, 64)
+		if err != nil {
+			continue
+		}
+		ids[id] = true
+	}
+	
+	return ids, nil
+}
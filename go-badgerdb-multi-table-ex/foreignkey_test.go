@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCreateOrderForeignKeyChecks(t *testing.T) {
+	s := newTestService(t, WithForeignKeyChecks(true))
+	
+	valid := &Order{UserID: 1, ProductID: 2, Quantity: 1, Amount: 49.99, Status: "pending"}
+	if err := s.CreateOrder(valid); err != nil {
+		t.Fatalf("valid order: %v", err)
+	}
+	if _, err := s.Orders().Get(valid.ID); err != nil {
+		t.Errorf("valid order was not stored: %v", err)
+	}
+	
+	bad := &Order{UserID: 42, ProductID: 2, Quantity: 1, Amount: 49.99, Status: "pending"}
+	if err := s.CreateOrder(bad); !errors.Is(err, ErrForeignKeyViolation) {
+		t.Fatalf("order of a missing user: got %v, want ErrForeignKeyViolation", err)
+	}
+	if _, err := s.Orders().Get(bad.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("rejected order: got %v, want ErrNotFound", err)
+	}
+	
+	// Without the option the same order is stored
+	unchecked := newTestService(t)
+	if err := unchecked.CreateOrder(&Order{UserID: 42, ProductID: 2, Quantity: 1, Amount: 49.99}); err != nil {
+		t.Errorf("unchecked service: %v", err)
+	}
+}
+
+func TestFindOrphanedOrders(t *testing.T) {
+	s := newTestService(t)
+	
+	orphans, err := s.FindOrphanedOrders()
+	if err != nil || len(orphans) != 0 {
+		t.Fatalf("seed data: got orphans %v, %v, want none", orphans, err)
+	}
+	
+	// Orders 1 and 4 are for the Laptop
+	if err := s.DeleteProduct(1); err != nil {
+		t.Fatalf("DeleteProduct: %v", err)
+	}
+	orphans, err = s.FindOrphanedOrders()
+	if err != nil {
+		t.Fatalf("FindOrphanedOrders: %v", err)
+	}
+	if len(orphans) != 2 || orphans[0] != 1 || orphans[1] != 4 {
+		t.Errorf("got orphans %v, want [1 4]", orphans)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// gcDiscardRatio is the discard ratio used by the background GC loop
+const gcDiscardRatio = 0.5
+
+// RunValueLogGC rewrites value log files until Badger reports there is
+// nothing left worth rewriting. A file is rewritten when at least
+// discardRatio of it is stale data from updates and deletes.
+func (s *BadgerService) RunValueLogGC(discardRatio float64) error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	
+	for {
+		err := s.db.RunValueLogGC(discardRatio)
+		if err == badger.ErrNoRewrite {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// StartGC runs value log GC every interval in the background until StopGC
+// or Close is called. Calling it while GC is already running does nothing.
+func (s *BadgerService) StartGC(interval time.Duration) {
+	s.gcMu.Lock()
+	defer s.gcMu.Unlock()
+	
+	if s.gcStop != nil {
+		return
+	}
+	
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	s.gcStop = stop
+	s.gcDone = done
+	
+	go func() {
+		defer close(done)
+		
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := s.RunValueLogGC(gcDiscardRatio); err != nil {
+					log.Printf("Value log GC failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// StopGC halts the background GC started by StartGC and waits for a run in
+// progress to finish. It is safe to call multiple times.
+func (s *BadgerService) StopGC() {
+	s.gcMu.Lock()
+	defer s.gcMu.Unlock()
+	
+	if s.gcStop == nil {
+		return
+	}
+	
+	close(s.gcStop)
+	<-s.gcDone
+	s.gcStop = nil
+	s.gcDone = nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunValueLogGC(t *testing.T) {
+	s, err := NewBadgerService(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewBadgerService: %v", err)
+	}
+	defer s.Close()
+	
+	for i := 0; i < 100; i++ {
+		category := &Category{Name: "Temporary"}
+		if err := s.CreateCategory(category); err != nil {
+			t.Fatalf("CreateCategory: %v", err)
+		}
+		if err := s.Categories().Delete(category.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+	}
+	
+	// Nothing worth rewriting is reported as success
+	if err := s.RunValueLogGC(gcDiscardRatio); err != nil {
+		t.Errorf("RunValueLogGC: %v", err)
+	}
+}
+
+func TestStartStopGC(t *testing.T) {
+	s, err := NewBadgerService(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewBadgerService: %v", err)
+	}
+	defer s.Close()
+	
+	s.StartGC(time.Millisecond)
+	s.StartGC(time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	s.StopGC()
+	s.StopGC()
+	
+	// GC can be started again after being stopped
+	s.StartGC(time.Millisecond)
+	s.StopGC()
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"go-baderdb-ex/badgerpb"
+)
+
+// grpcServer implements badgerpb.BadgerServiceServer by delegating to the
+// service
+type grpcServer struct {
+	badgerpb.UnimplementedBadgerServiceServer
+	s *BadgerService
+}
+
+// NewGRPCServer returns a gRPC server with the BadgerService registered
+func NewGRPCServer(s *BadgerService) *grpc.Server {
+	srv := grpc.NewServer()
+	badgerpb.RegisterBadgerServiceServer(srv, &grpcServer{s: s})
+	return srv
+}
+
+// ServeGRPC serves the gRPC API on addr until ctx is cancelled, then waits
+// for in-flight calls to finish
+func ServeGRPC(ctx context.Context, addr string, s *BadgerService) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	
+	srv := NewGRPCServer(s)
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+	
+	return srv.Serve(lis)
+}
+
+// grpcError maps service errors to gRPC status codes
+func grpcError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, badger.ErrKeyNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrForeignKeyViolation):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrReadOnly):
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// Users
+
+func (g *grpcServer) CreateUser(ctx context.Context, in *badgerpb.User) (*badgerpb.User, error) {
+	user := userFromProto(in)
+	if err := g.s.CreateUser(user); err != nil {
+		return nil, grpcError(err)
+	}
+	return userToProto(user), nil
+}
+
+func (g *grpcServer) GetUser(ctx context.Context, in *badgerpb.EntityID) (*badgerpb.User, error) {
+	user, err := g.s.Users().Get(in.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return userToProto(user), nil
+}
+
+func (g *grpcServer) UpdateUser(ctx context.Context, in *badgerpb.User) (*badgerpb.User, error) {
+	old, err := g.s.Users().Get(in.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	
+	user := userFromProto(in)
+	if in.GetCreatedAt() == nil {
+		user.CreatedAt = old.CreatedAt
+	}
+	if err := g.s.Users().Update(user); err != nil {
+		return nil, grpcError(err)
+	}
+	return userToProto(user), nil
+}
+
+func (g *grpcServer) DeleteUser(ctx context.Context, in *badgerpb.EntityID) (*emptypb.Empty, error) {
+	if _, err := g.s.Users().Get(in.GetId()); err != nil {
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, grpcError(g.s.Users().Delete(in.GetId()))
+}
+
+// Companies
+
+func (g *grpcServer) CreateCompany(ctx context.Context, in *badgerpb.Company) (*badgerpb.Company, error) {
+	company := companyFromProto(in)
+	if err := g.s.CreateCompany(company); err != nil {
+		return nil, grpcError(err)
+	}
+	return companyToProto(company), nil
+}
+
+func (g *grpcServer) GetCompany(ctx context.Context, in *badgerpb.EntityID) (*badgerpb.Company, error) {
+	company, err := g.s.Companies().Get(in.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return companyToProto(company), nil
+}
+
+func (g *grpcServer) UpdateCompany(ctx context.Context, in *badgerpb.Company) (*badgerpb.Company, error) {
+	old, err := g.s.Companies().Get(in.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	
+	company := companyFromProto(in)
+	if in.GetCreatedAt() == nil {
+		company.CreatedAt = old.CreatedAt
+	}
+	if err := g.s.Companies().Update(company); err != nil {
+		return nil, grpcError(err)
+	}
+	return companyToProto(company), nil
+}
+
+func (g *grpcServer) DeleteCompany(ctx context.Context, in *badgerpb.EntityID) (*emptypb.Empty, error) {
+	if _, err := g.s.Companies().Get(in.GetId()); err != nil {
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, grpcError(g.s.Companies().Delete(in.GetId()))
+}
+
+// Orders
+
+func (g *grpcServer) CreateOrder(ctx context.Context, in *badgerpb.Order) (*badgerpb.Order, error) {
+	order := orderFromProto(in)
+	if err := g.s.CreateOrder(order); err != nil {
+		return nil, grpcError(err)
+	}
+	return orderToProto(order), nil
+}
+
+func (g *grpcServer) GetOrder(ctx context.Context, in *badgerpb.EntityID) (*badgerpb.Order, error) {
+	order, err := g.s.Orders().Get(in.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return orderToProto(order), nil
+}
+
+func (g *grpcServer) UpdateOrder(ctx context.Context, in *badgerpb.Order) (*badgerpb.Order, error) {
+	old, err := g.s.Orders().Get(in.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	
+	order := orderFromProto(in)
+	if in.GetCreatedAt() == nil {
+		order.CreatedAt = old.CreatedAt
+	}
+	if err := g.s.UpdateOrder(order); err != nil {
+		return nil, grpcError(err)
+	}
+	return orderToProto(order), nil
+}
+
+func (g *grpcServer) DeleteOrder(ctx context.Context, in *badgerpb.EntityID) (*emptypb.Empty, error) {
+	if _, err := g.s.Orders().Get(in.GetId()); err != nil {
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, grpcError(g.s.DeleteOrder(in.GetId()))
+}
+
+// Products
+
+func (g *grpcServer) CreateProduct(ctx context.Context, in *badgerpb.Product) (*badgerpb.Product, error) {
+	product := productFromProto(in)
+	if err := g.s.CreateProduct(product); err != nil {
+		return nil, grpcError(err)
+	}
+	return productToProto(product), nil
+}
+
+func (g *grpcServer) GetProduct(ctx context.Context, in *badgerpb.EntityID) (*badgerpb.Product, error) {
+	product, err := g.s.Products().Get(in.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return productToProto(product), nil
+}
+
+func (g *grpcServer) UpdateProduct(ctx context.Context, in *badgerpb.Product) (*badgerpb.Product, error) {
+	product := productFromProto(in)
+	if err := g.s.UpdateProduct(product); err != nil {
+		return nil, grpcError(err)
+	}
+	return productToProto(product), nil
+}
+
+func (g *grpcServer) DeleteProduct(ctx context.Context, in *badgerpb.EntityID) (*emptypb.Empty, error) {
+	if _, err := g.s.Products().Get(in.GetId()); err != nil {
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, grpcError(g.s.DeleteProduct(in.GetId()))
+}
+
+// Categories
+
+func (g *grpcServer) CreateCategory(ctx context.Context, in *badgerpb.Category) (*badgerpb.Category, error) {
+	category := categoryFromProto(in)
+	if err := g.s.CreateCategory(category); err != nil {
+		return nil, grpcError(err)
+	}
+	return categoryToProto(category), nil
+}
+
+func (g *grpcServer) GetCategory(ctx context.Context, in *badgerpb.EntityID) (*badgerpb.Category, error) {
+	category, err := g.s.Categories().Get(in.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return categoryToProto(category), nil
+}
+
+func (g *grpcServer) UpdateCategory(ctx context.Context, in *badgerpb.Category) (*badgerpb.Category, error) {
+	category := categoryFromProto(in)
+	if err := g.s.Categories().Update(category); err != nil {
+		return nil, grpcError(err)
+	}
+	return categoryToProto(category), nil
+}
+
+func (g *grpcServer) DeleteCategory(ctx context.Context, in *badgerpb.EntityID) (*emptypb.Empty, error) {
+	if _, err := g.s.Categories().Get(in.GetId()); err != nil {
+		return nil, grpcError(err)
+	}
+	return &emptypb.Empty{}, grpcError(g.s.Categories().Delete(in.GetId()))
+}
+
+// Joins
+
+func (g *grpcServer) GetOrdersWithDetails(ctx context.Context, in *emptypb.Empty) (*badgerpb.GetOrdersWithDetailsResponse, error) {
+	details, err := g.s.GetOrdersWithDetails()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	
+	resp := &badgerpb.GetOrdersWithDetailsResponse{}
+	for i := range details {
+		od := &details[i]
+		pb := &badgerpb.OrderWithDetails{
+			Order:    orderToProto(&od.Order),
+			User:     userToProto(&od.User),
+			Product:  productToProto(&od.Product),
+			Category: categoryToProto(&od.Category),
+		}
+		for j := range od.Lines {
+			line := &od.Lines[j]
+			pb.Lines = append(pb.Lines, &badgerpb.LineDetails{
+				Item:     lineItemToProto(&line.Item),
+				Product:  productToProto(&line.Product),
+				Category: categoryToProto(&line.Category),
+			})
+		}
+		resp.Orders = append(resp.Orders, pb)
+	}
+	return resp, nil
+}
+
+// Conversions between the entity structs and their messages. A zero
+// time.Time maps to an unset timestamp and back.
+
+func timestampToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func timestampFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func userToProto(u *User) *badgerpb.User {
+	return &badgerpb.User{
+		Id:        u.ID,
+		Name:      u.Name,
+		Email:     u.Email,
+		CompanyId: u.CompanyID,
+		CreatedAt: timestampToProto(u.CreatedAt),
+	}
+}
+
+func userFromProto(u *badgerpb.User) *User {
+	return &User{
+		ID:        u.GetId(),
+		Name:      u.GetName(),
+		Email:     u.GetEmail(),
+		CompanyID: u.GetCompanyId(),
+		CreatedAt: timestampFromProto(u.GetCreatedAt()),
+	}
+}
+
+func companyToProto(c *Company) *badgerpb.Company {
+	return &badgerpb.Company{
+		Id:        c.ID,
+		Name:      c.Name,
+		Industry:  c.Industry,
+		CreatedAt: timestampToProto(c.CreatedAt),
+	}
+}
+
+func companyFromProto(c *badgerpb.Company) *Company {
+	return &Company{
+		ID:        c.GetId(),
+		Name:      c.GetName(),
+		Industry:  c.GetIndustry(),
+		CreatedAt: timestampFromProto(c.GetCreatedAt()),
+	}
+}
+
+func orderToProto(o *Order) *badgerpb.Order {
+	pb := &badgerpb.Order{
+		Id:        o.ID,
+		UserId:    o.UserID,
+		ProductId: o.ProductID,
+		Quantity:  int64(o.Quantity),
+		Amount:    o.Amount,
+		Status:    o.Status,
+		CreatedAt: timestampToProto(o.CreatedAt),
+	}
+	for i := range o.LineItems {
+		pb.LineItems = append(pb.LineItems, lineItemToProto(&o.LineItems[i]))
+	}
+	return pb
+}
+
+func lineItemToProto(item *LineItem) *badgerpb.LineItem {
+	return &badgerpb.LineItem{
+		ProductId: item.ProductID,
+		Quantity:  int64(item.Quantity),
+		UnitPrice: item.UnitPrice,
+	}
+}
+
+func orderFromProto(o *badgerpb.Order) *Order {
+	order := &Order{
+		ID:        o.GetId(),
+		UserID:    o.GetUserId(),
+		ProductID: o.GetProductId(),
+		Quantity:  int(o.GetQuantity()),
+		Amount:    o.GetAmount(),
+		Status:    o.GetStatus(),
+		CreatedAt: timestampFromProto(o.GetCreatedAt()),
+	}
+	for _, item := range o.GetLineItems() {
+		order.LineItems = append(order.LineItems, LineItem{
+			ProductID: item.GetProductId(),
+			Quantity:  int(item.GetQuantity()),
+			UnitPrice: item.GetUnitPrice(),
+		})
+	}
+	return order
+}
+
+func productToProto(p *Product) *badgerpb.Product {
+	return &badgerpb.Product{
+		Id:          p.ID,
+		Name:        p.Name,
+		Price:       p.Price,
+		CategoryId:  p.CategoryID,
+		CompanyId:   p.CompanyID,
+		Description: p.Description,
+	}
+}
+
+func productFromProto(p *badgerpb.Product) *Product {
+	return &Product{
+		ID:          p.GetId(),
+		Name:        p.GetName(),
+		Price:       p.GetPrice(),
+		CategoryID:  p.GetCategoryId(),
+		CompanyID:   p.GetCompanyId(),
+		Description: p.GetDescription(),
+	}
+}
+
+func categoryToProto(c *Category) *badgerpb.Category {
+	return &badgerpb.Category{Id: c.ID, Name: c.Name}
+}
+
+func categoryFromProto(c *badgerpb.Category) *Category {
+	return &Category{ID: c.GetId(), Name: c.GetName()}
+}
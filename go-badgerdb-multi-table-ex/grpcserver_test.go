@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"go-baderdb-ex/badgerpb"
+)
+
+// newTestGRPCClient serves s over an in-memory listener and returns a client
+// connected to it, both stopped when the test ends
+func newTestGRPCClient(t *testing.T, s *BadgerService) badgerpb.BadgerServiceClient {
+	t.Helper()
+	
+	lis := bufconn.Listen(1 << 20)
+	srv := NewGRPCServer(s)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("grpc.NewClient: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	
+	return badgerpb.NewBadgerServiceClient(conn)
+}
+
+func TestGRPCCreateUserGetUser(t *testing.T) {
+	s := newTestService(t)
+	client := newTestGRPCClient(t, s)
+	ctx := context.Background()
+	
+	created, err := client.CreateUser(ctx, &badgerpb.User{Name: "Dana", Email: "dana@example.com", CompanyId: 1})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if created.GetId() == 0 {
+		t.Fatal("CreateUser returned no ID")
+	}
+	
+	got, err := client.GetUser(ctx, &badgerpb.EntityID{Id: created.GetId()})
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if got.GetName() != "Dana" || got.GetEmail() != "dana@example.com" || got.GetCompanyId() != 1 {
+		t.Errorf("GetUser = %v, want the created user", got)
+	}
+	if !got.GetCreatedAt().AsTime().Equal(testClock) {
+		t.Errorf("created_at = %v, want %v", got.GetCreatedAt().AsTime(), testClock)
+	}
+	
+	_, err = client.GetUser(ctx, &badgerpb.EntityID{Id: 999})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("GetUser of a missing user: got %v, want NOT_FOUND", err)
+	}
+}
+
+func TestGRPCOrdersWithDetailsLines(t *testing.T) {
+	s := newTestService(t)
+	client := newTestGRPCClient(t, s)
+	
+	order := &Order{
+		UserID: 1,
+		LineItems: []LineItem{
+			{ProductID: 1, Quantity: 1, UnitPrice: 999.99},
+			{ProductID: 2, Quantity: 2, UnitPrice: 49.99},
+		},
+		Amount: 1099.97,
+		Status: "pending",
+	}
+	if err := s.CreateOrder(order); err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	
+	resp, err := client.GetOrdersWithDetails(context.Background(), &emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("GetOrdersWithDetails: %v", err)
+	}
+	
+	var got *badgerpb.OrderWithDetails
+	for _, od := range resp.GetOrders() {
+		if od.GetOrder().GetId() == order.ID {
+			got = od
+		}
+	}
+	if got == nil {
+		t.Fatalf("order %d missing from the response", order.ID)
+	}
+	
+	wantLines := []struct {
+		product, category string
+		quantity          int64
+	}{
+		{"Laptop", "Electronics", 1},
+		{"Programming Book", "Books", 2},
+	}
+	if len(got.GetLines()) != len(wantLines) {
+		t.Fatalf("got %d lines, want %d", len(got.GetLines()), len(wantLines))
+	}
+	for i, want := range wantLines {
+		line := got.GetLines()[i]
+		if line.GetProduct().GetName() != want.product || line.GetCategory().GetName() != want.category || line.GetItem().GetQuantity() != want.quantity {
+			t.Errorf("line %d = %s/%s x%d, want %s/%s x%d", i,
+				line.GetProduct().GetName(), line.GetCategory().GetName(), line.GetItem().GetQuantity(),
+				want.product, want.category, want.quantity)
+		}
+	}
+	if got.GetProduct().GetName() != "Laptop" {
+		t.Errorf("product = %q, want the first line's Laptop", got.GetProduct().GetName())
+	}
+}
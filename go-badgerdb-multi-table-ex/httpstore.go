@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"go-baderdb-ex/server"
+)
+
+// httpStore adapts the service to server.Store
+type httpStore struct {
+	s        *BadgerService
+	entities map[string]entityStore
+}
+
+// entityStore is the untyped CRUD of one entity
+type entityStore interface {
+	create(body json.RawMessage) (interface{}, error)
+	get(id int64) (interface{}, error)
+	update(id int64, body json.RawMessage) (interface{}, error)
+	delete(id int64) error
+	list() (interface{}, error)
+}
+
+func newHTTPStore(s *BadgerService) *httpStore {
+	return &httpStore{
+		s: s,
+		entities: map[string]entityStore{
+			"users":      repoStore[User]{s.Users(), s.CreateUser},
+			"companies":  repoStore[Company]{s.Companies(), s.CreateCompany},
+			"orders":     repoStore[Order]{s.Orders(), s.CreateOrder},
+			"products":   repoStore[Product]{s.Products(), s.CreateProduct},
+			"categories": repoStore[Category]{s.Categories(), s.CreateCategory},
+		},
+	}
+}
+
+func (h *httpStore) entity(name string) (entityStore, error) {
+	e, ok := h.entities[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown entity %s", server.ErrNotFound, name)
+	}
+	return e, nil
+}
+
+func (h *httpStore) Create(entity string, body json.RawMessage) (interface{}, error) {
+	e, err := h.entity(entity)
+	if err != nil {
+		return nil, err
+	}
+	return e.create(body)
+}
+
+func (h *httpStore) Get(entity string, id int64) (interface{}, error) {
+	e, err := h.entity(entity)
+	if err != nil {
+		return nil, err
+	}
+	return e.get(id)
+}
+
+func (h *httpStore) Update(entity string, id int64, body json.RawMessage) (interface{}, error) {
+	e, err := h.entity(entity)
+	if err != nil {
+		return nil, err
+	}
+	return e.update(id, body)
+}
+
+func (h *httpStore) Delete(entity string, id int64) error {
+	e, err := h.entity(entity)
+	if err != nil {
+		return err
+	}
+	return e.delete(id)
+}
+
+func (h *httpStore) List(entity string) (interface{}, error) {
+	e, err := h.entity(entity)
+	if err != nil {
+		return nil, err
+	}
+	return e.list()
+}
+
+func (h *httpStore) OrdersWithDetails() (interface{}, error) {
+	return h.s.GetOrdersWithDetails()
+}
+
+func (h *httpStore) CompanyStats() (interface{}, error) {
+	return h.s.GetCompanyStats()
+}
+
+// repoStore serves an entity through its repository. createFn is the entity's
+// Create* method, which also fills in fields such as CreatedAt.
+type repoStore[T any] struct {
+	r        *Repository[T]
+	createFn func(*T) error
+}
+
+func (rs repoStore[T]) create(body json.RawMessage) (interface{}, error) {
+	var item T
+	if err := json.Unmarshal(body, &item); err != nil {
+		return nil, fmt.Errorf("%w: %v", server.ErrBadRequest, err)
+	}
+	
+	if err := rs.createFn(&item); err != nil {
+		return nil, httpError(err)
+	}
+	return &item, nil
+}
+
+func (rs repoStore[T]) get(id int64) (interface{}, error) {
+	item, err := rs.r.Get(id)
+	if err != nil {
+		return nil, httpError(err)
+	}
+	return item, nil
+}
+
+// update applies the fields present in body to the stored record, so fields
+// the client leaves out, such as CreatedAt, keep their value
+func (rs repoStore[T]) update(id int64, body json.RawMessage) (interface{}, error) {
+	item, err := rs.r.Get(id)
+	if err != nil {
+		return nil, httpError(err)
+	}
+	
+	if err := json.Unmarshal(body, item); err != nil {
+		return nil, fmt.Errorf("%w: %v", server.ErrBadRequest, err)
+	}
+	*rs.r.id(item) = id
+	
+	if err := rs.r.Update(item); err != nil {
+		return nil, httpError(err)
+	}
+	return item, nil
+}
+
+func (rs repoStore[T]) delete(id int64) error {
+	if _, err := rs.r.Get(id); err != nil {
+		return httpError(err)
+	}
+	return httpError(rs.r.Delete(id))
+}
+
+func (rs repoStore[T]) list() (interface{}, error) {
+	items, err := rs.r.List()
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []T{}
+	}
+	return items, nil
+}
+
+// httpError wraps the service errors that map to a client error
+func httpError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, badger.ErrKeyNotFound):
+		return fmt.Errorf("%w: %v", server.ErrNotFound, err)
+	case errors.Is(err, ErrForeignKeyViolation):
+		return fmt.Errorf("%w: %v", server.ErrBadRequest, err)
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-baderdb-ex/server"
+)
+
+func TestHTTPPayloadsMatchTheService(t *testing.T) {
+	s := newTestService(t)
+	handler := server.NewHandler(newHTTPStore(s))
+	
+	user, err := s.Users().Get(1)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	details, err := s.GetOrdersWithDetails()
+	if err != nil {
+		t.Fatalf("GetOrdersWithDetails: %v", err)
+	}
+	stats, err := s.GetCompanyStats()
+	if err != nil {
+		t.Fatalf("GetCompanyStats: %v", err)
+	}
+	
+	for _, tc := range []struct {
+		path string
+		want interface{}
+	}{
+		{"/users/1", user},
+		{"/orders/details", details},
+		{"/companies/stats", stats},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", tc.path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: status %d: %s", tc.path, rec.Code, rec.Body)
+			continue
+		}
+		
+		want, err := json.Marshal(tc.want)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		if got := bytes.TrimSpace(rec.Body.Bytes()); !bytes.Equal(got, want) {
+			t.Errorf("GET %s:\n got %s\nwant %s", tc.path, got, want)
+		}
+	}
+	
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/users/99", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /users/99: status %d, want 404", rec.Code)
+	}
+}
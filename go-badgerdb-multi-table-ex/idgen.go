@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strconv"
+)
+
+// IDGenerator supplies the keys of records created with CreateGenerated
+type IDGenerator interface {
+	NextID(s *BadgerService, entity string) (string, error)
+}
+
+// SequentialIDGenerator hands out the auto-increment IDs Create assigns. It
+// is the default.
+type SequentialIDGenerator struct{}
+
+func (SequentialIDGenerator) NextID(s *BadgerService, entity string) (string, error) {
+	id, err := s.getNextID(entity)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+// UUIDGenerator hands out random version 4 UUIDs. They reveal nothing about
+// how many records exist and need no shared counter.
+type UUIDGenerator struct{}
+
+func (UUIDGenerator) NextID(s *BadgerService, entity string) (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// CreateGenerated stores item under a key from the service's IDGenerator and
+// returns the key. With SequentialIDGenerator this is Create. Any other key,
+// e.g. a UUID with UUIDGenerator, is stored under "gen:<entity>:<key>" and the
+// record's ID is left as is. Such a record is not indexed, audited, checked
+// for foreign keys or counted, and List and the joins leave it out, so read
+// it back with GetByKey.
+func (r *Repository[T]) CreateGenerated(item *T) (string, error) {
+	if err := r.s.checkWritable(); err != nil {
+		return "", err
+	}
+	
+	key, err := r.s.idGenerator.NextID(r.s, r.entity)
+	if err != nil {
+		return "", err
+	}
+	
+	if id, err := strconv.ParseInt(key, 10, 64); err == nil {
+		*r.id(item) = id
+		return key, r.s.create(r.entity, id, item)
+	}
+	return key, r.s.createGen(r.entity, key, item)
+}
+
+// GetByKey reads the record stored under key, a numeric ID or a string key
+// returned by CreateGenerated
+func (r *Repository[T]) GetByKey(key string) (*T, error) {
+	if id, err := strconv.ParseInt(key, 10, 64); err == nil {
+		return r.Get(id)
+	}
+	
+	var item T
+	if err := r.s.getGen(r.entity, key, &item); err != nil {
+		return nil, err
+	}
+	
+	return &item, nil
+}
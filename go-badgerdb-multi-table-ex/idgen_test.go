@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestCreateGeneratedUUID(t *testing.T) {
+	s := newTestService(t, WithIDGenerator(UUIDGenerator{}))
+	users := s.Users()
+	
+	keys := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		email := fmt.Sprintf("user%d@example.com", i)
+		key, err := users.CreateGenerated(&User{Name: "User", Email: email})
+		if err != nil {
+			t.Fatalf("CreateGenerated: %v", err)
+		}
+		if !uuidPattern.MatchString(key) {
+			t.Errorf("key %q is not a version 4 UUID", key)
+		}
+		if _, dup := keys[key]; dup {
+			t.Fatalf("key %q generated twice", key)
+		}
+		keys[key] = email
+	}
+	
+	for key, email := range keys {
+		user, err := users.GetByKey(key)
+		if err != nil {
+			t.Fatalf("GetByKey(%q): %v", key, err)
+		}
+		if user.Email != email {
+			t.Errorf("GetByKey(%q) = %s, want %s", key, user.Email, email)
+		}
+	}
+}
+
+func TestCreateGeneratedSequential(t *testing.T) {
+	s := newTestService(t)
+	
+	user := &User{Name: "Dana", Email: "dana@example.com"}
+	key, err := s.Users().CreateGenerated(user)
+	if err != nil {
+		t.Fatalf("CreateGenerated: %v", err)
+	}
+	if key != "4" || user.ID != 4 {
+		t.Errorf("got key %q and ID %d, want the next sequential ID 4", key, user.ID)
+	}
+	
+	got, err := s.Users().GetByKey(key)
+	if err != nil || got.Name != "Dana" {
+		t.Errorf("GetByKey(%q) = %v, %v", key, got, err)
+	}
+}
+
+func TestCreateGeneratedStaysOutOfScans(t *testing.T) {
+	s := newTestService(t, WithIDGenerator(UUIDGenerator{}))
+	
+	userKey, err := s.Users().CreateGenerated(&User{Name: "Dana", Email: "dana@example.com", CompanyID: 1})
+	if err != nil {
+		t.Fatalf("CreateGenerated: %v", err)
+	}
+	if _, err := s.Orders().CreateGenerated(&Order{UserID: 1, ProductID: 1, Amount: 5, Status: "pending"}); err != nil {
+		t.Fatalf("CreateGenerated: %v", err)
+	}
+	
+	users, err := s.Users().List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(users) != 3 {
+		t.Errorf("List returned %d users, want the 3 seeded ones", len(users))
+	}
+	for _, user := range users {
+		if user.ID == 0 {
+			t.Errorf("List returned %s with ID 0", user.Name)
+		}
+	}
+	
+	joined, err := s.GetUsersWithCompanies()
+	if err != nil {
+		t.Fatalf("GetUsersWithCompanies: %v", err)
+	}
+	if len(joined) != 3 {
+		t.Errorf("GetUsersWithCompanies returned %d users, want 3", len(joined))
+	}
+	for _, u := range joined {
+		if u.User.ID == 0 {
+			t.Errorf("GetUsersWithCompanies returned %s with ID 0", u.User.Name)
+		}
+	}
+	
+	orders, err := s.GetOrdersWithDetails()
+	if err != nil {
+		t.Fatalf("GetOrdersWithDetails: %v", err)
+	}
+	if len(orders) != 4 {
+		t.Errorf("GetOrdersWithDetails returned %d orders, want 4", len(orders))
+	}
+	for _, o := range orders {
+		if o.Order.ID == 0 {
+			t.Errorf("GetOrdersWithDetails returned an order with ID 0")
+		}
+	}
+	
+	if count, err := s.Count("users"); err != nil || count != 3 {
+		t.Errorf("Count = %d, %v, want 3", count, err)
+	}
+	if user, err := s.Users().GetByKey(userKey); err != nil || user.Name != "Dana" {
+		t.Errorf("GetByKey(%q) = %v, %v, want Dana", userKey, user, err)
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// secondaryIndex maps a record to the value it is indexed under.
+// Index entries are stored as empty values at idx:<entity>:<name>:<value>:<id>,
+// prefixed with the tenant if the service has one.
+type secondaryIndex struct {
+	name  string
+	value func(record interface{}) string
+}
+
+// entityIndexes lists the secondary indexes maintained for each entity.
+// Records are always passed as pointers to their struct type.
+var entityIndexes = map[string][]secondaryIndex{
+	"orders": {
+		{name: "user", value: func(record interface{}) string {
+			return strconv.FormatInt(record.(*Order).UserID, 10)
+		}},
+	},
+	"products": {
+		{name: "category", value: func(record interface{}) string {
+			return strconv.FormatInt(record.(*Product).CategoryID, 10)
+		}},
+	},
+	"categories": {
+		{name: "name", value: func(record interface{}) string {
+			return record.(*Category).Name
+		}},
+	},
+}
+
+// entityTypes returns an empty record for each entity, used to decode the
+// previous version of a record when its index entries need updating
+var entityTypes = map[string]func() interface{}{
+	"users":      func() interface{} { return &User{} },
+	"companies":  func() interface{} { return &Company{} },
+	"orders":     func() interface{} { return &Order{} },
+	"products":   func() interface{} { return &Product{} },
+	"categories": func() interface{} { return &Category{} },
+}
+
+func (s *BadgerService) indexPrefix(entity, name, value string) []byte {
+	return s.scopedKey(s.joinKey("idx", entity, name, value, ""))
+}
+
+func (s *BadgerService) indexKey(entity, name, value string, id int64) []byte {
+	return append(s.indexPrefix(entity, name, value), strconv.FormatInt(id, 10)...)
+}
+
+// indexKeys returns every index key a record should be reachable from
+func (s *BadgerService) indexKeys(entity string, id int64, record interface{}) map[string]struct{} {
+	keys := make(map[string]struct{})
+	for _, idx := range entityIndexes[entity] {
+		keys[string(s.indexKey(entity, idx.name, idx.value(record), id))] = struct{}{}
+	}
+	return keys
+}
+
+// storedIndexKeys returns the index keys of the record currently stored under
+// key, or nil if there is no such record
+func (s *BadgerService) storedIndexKeys(txn *badger.Txn, entity string, id int64, key []byte) (map[string]struct{}, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	old := entityTypes[entity]()
+	err = item.Value(func(val []byte) error {
+		return s.codec.Unmarshal(val, old)
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	return s.indexKeys(entity, id, old), nil
+}
+
+// updateIndexes replaces the index entries of the stored record with the ones
+// derived from record, dropping entries whose indexed value has changed
+func (s *BadgerService) updateIndexes(txn *badger.Txn, entity string, id int64, key []byte, record interface{}) error {
+	if len(entityIndexes[entity]) == 0 {
+		return nil
+	}
+	
+	oldKeys, err := s.storedIndexKeys(txn, entity, id, key)
+	if err != nil {
+		return err
+	}
+	
+	newKeys := s.indexKeys(entity, id, record)
+	for k := range oldKeys {
+		if _, keep := newKeys[k]; keep {
+			continue
+		}
+		if err := txn.Delete([]byte(k)); err != nil {
+			return err
+		}
+	}
+	
+	for k := range newKeys {
+		if err := txn.Set([]byte(k), nil); err != nil {
+			return err
+		}
+	}
+	
+	return nil
+}
+
+// removeIndexes deletes the index entries of the stored record
+func (s *BadgerService) removeIndexes(txn *badger.Txn, entity string, id int64, key []byte) error {
+	if len(entityIndexes[entity]) == 0 {
+		return nil
+	}
+	
+	oldKeys, err := s.storedIndexKeys(txn, entity, id, key)
+	if err != nil {
+		return err
+	}
+	
+	for k := range oldKeys {
+		if err := txn.Delete([]byte(k)); err != nil {
+			return err
+		}
+	}
+	
+	return nil
+}
+
+// lookupIndex returns the IDs of the records indexed under value
+func (s *BadgerService) lookupIndex(txn *badger.Txn, entity, name, value string) ([]int64, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false // Index entries carry no value
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	
+	prefix := s.indexPrefix(entity, name, value)
+	var ids []int64
+	
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		suffix := strings.TrimPrefix(string(it.Item().Key()), string(prefix))
+		id, err := strconv.ParseInt(suffix, 10, 64)
+		if err != nil {
+			continue // Longer index value sharing this prefix
+		}
+		ids = append(ids, id)
+	}
+	
+	return ids, nil
+}
+
+// RebuildIndexes drops every index entry and derives them again from the
+// stored records that are not soft-deleted, e.g. after records were imported
+// without going through the service. The new entries are written with a write batch, which commits them
+// in as many transactions as needed. It must not run concurrently with other
+// writes.
+func (s *BadgerService) RebuildIndexes() error {
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	
+	prefix := s.scopedKey(s.joinKey("idx", ""))
+	if err := s.db.DropPrefix(prefix); err != nil {
+		if _, err := s.deleteKeysWithPrefix(prefix); err != nil {
+			return err
+		}
+	}
+	
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+	
+	for entity := range entityIndexes {
+		err := s.view(func(txn *badger.Txn) error {
+			deleted, err := s.softDeletedIDs(txn, entity)
+			if err != nil {
+				return err
+			}
+			
+			opts := s.iteratorOptions()
+			opts.Prefix = s.entityPrefix(entity)
+			it := txn.NewIterator(opts)
+			defer it.Close()
+			
+			for it.Rewind(); it.Valid(); it.Next() {
+				item := it.Item()
+				id, err := strconv.ParseInt(string(item.Key()[len(opts.Prefix):]), 10, 64)
+				if err != nil {
+					continue
+				}
+				if _, ok := deleted[id]; ok {
+					continue
+				}
+				
+				record := entityTypes[entity]()
+				err = item.Value(func(val []byte) error {
+					return s.codec.Unmarshal(val, record)
+				})
+				if err != nil {
+					return err
+				}
+				
+				for k := range s.indexKeys(entity, id, record) {
+					if err := wb.Set([]byte(k), nil); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	
+	return wb.Flush()
+}
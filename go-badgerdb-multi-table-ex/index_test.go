@@ -0,0 +1,200 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// indexedIDs returns the IDs stored in the index of entity under name=value
+func indexedIDs(t *testing.T, s *BadgerService, entity, name, value string) []int64 {
+	t.Helper()
+	
+	var ids []int64
+	err := s.view(func(txn *badger.Txn) error {
+		var err error
+		ids, err = s.lookupIndex(txn, entity, name, value)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("lookupIndex %s %s=%s: %v", entity, name, value, err)
+	}
+	return ids
+}
+
+func TestGetUserOrdersWithProductsReadsOnlyTheUsersOrders(t *testing.T) {
+	s := newTestService(t)
+	
+	// The seeded users are 1, 2 and 3, Alice already has orders 1 and 3
+	want := map[int64]bool{1: true, 3: true}
+	err := s.WithTransaction(func(tx *Tx) error {
+		for i := 0; i < 1000; i++ {
+			order := &Order{UserID: int64(i%3 + 1), ProductID: 3, Quantity: 1, Amount: 19.99, Status: "completed"}
+			if err := tx.CreateOrder(order); err != nil {
+				return err
+			}
+			if order.UserID == 1 {
+				want[order.ID] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+	
+	// The index is all GetUserOrdersWithProducts reads orders through
+	ids := indexedIDs(t, s, "orders", "user", "1")
+	if len(ids) != len(want) {
+		t.Fatalf("index holds %d orders of user 1, want %d", len(ids), len(want))
+	}
+	for _, id := range ids {
+		if !want[id] {
+			t.Errorf("index of user 1 holds order %d", id)
+		}
+	}
+	
+	got, err := s.GetUserOrdersWithProducts(1)
+	if err != nil {
+		t.Fatalf("GetUserOrdersWithProducts: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d orders, want %d", len(got), len(want))
+	}
+	for _, od := range got {
+		if od.Order.UserID != 1 || !want[od.Order.ID] {
+			t.Errorf("got order %d of user %d", od.Order.ID, od.Order.UserID)
+		}
+	}
+}
+
+func TestOrderUserIndexFollowsUpdates(t *testing.T) {
+	s := newTestService(t)
+	
+	order, err := s.Orders().Get(3)
+	if err != nil {
+		t.Fatalf("Get order 3: %v", err)
+	}
+	order.UserID = 2
+	if err := s.UpdateOrder(order); err != nil {
+		t.Fatalf("UpdateOrder: %v", err)
+	}
+	
+	for _, id := range indexedIDs(t, s, "orders", "user", "1") {
+		if id == 3 {
+			t.Errorf("order 3 is still indexed under user 1")
+		}
+	}
+	if !containsID(indexedIDs(t, s, "orders", "user", "2"), 3) {
+		t.Errorf("order 3 is not indexed under user 2")
+	}
+	
+	if err := s.DeleteOrder(3); err != nil {
+		t.Fatalf("DeleteOrder: %v", err)
+	}
+	if containsID(indexedIDs(t, s, "orders", "user", "2"), 3) {
+		t.Errorf("deleted order 3 is still indexed under user 2")
+	}
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestProductCategoryIndexFollowsReassignment(t *testing.T) {
+	s := newTestService(t)
+	
+	product, err := s.Products().Get(1)
+	if err != nil {
+		t.Fatalf("Get product 1: %v", err)
+	}
+	product.CategoryID = 2
+	if err := s.UpdateProduct(product); err != nil {
+		t.Fatalf("UpdateProduct: %v", err)
+	}
+	
+	electronics, err := s.GetProductsByCategory(1)
+	if err != nil {
+		t.Fatalf("GetProductsByCategory(1): %v", err)
+	}
+	if len(electronics) != 0 {
+		t.Errorf("category 1 still lists %+v", electronics)
+	}
+	if ids := indexedIDs(t, s, "products", "category", "1"); len(ids) != 0 {
+		t.Errorf("category 1 index still holds %v", ids)
+	}
+	
+	books, err := s.GetProductsByCategory(2)
+	if err != nil {
+		t.Fatalf("GetProductsByCategory(2): %v", err)
+	}
+	names := make(map[string]bool)
+	for _, p := range books {
+		names[p.Name] = true
+	}
+	if len(books) != 2 || !names["Laptop"] || !names["Programming Book"] {
+		t.Errorf("category 2 lists %+v, want the Laptop and the Programming Book", books)
+	}
+}
+
+func TestRebuildIndexesRepairsAWrongMapping(t *testing.T) {
+	s := newTestService(t)
+	
+	// Move the laptop to the clothing category in the index only, as a
+	// manual import that skipped the service would
+	err := s.db.Update(func(txn *badger.Txn) error {
+		if err := txn.Delete(s.indexKey("products", "category", "1", 1)); err != nil {
+			return err
+		}
+		return txn.Set(s.indexKey("products", "category", "3", 1), nil)
+	})
+	if err != nil {
+		t.Fatalf("corrupting the index: %v", err)
+	}
+	if !containsID(indexedIDs(t, s, "products", "category", "3"), 1) {
+		t.Fatal("the wrong mapping was not written")
+	}
+	
+	if err := s.RebuildIndexes(); err != nil {
+		t.Fatalf("RebuildIndexes: %v", err)
+	}
+	
+	if ids := indexedIDs(t, s, "products", "category", "3"); len(ids) != 1 || ids[0] != 3 {
+		t.Errorf("category 3 index holds %v, want only the T-Shirt", ids)
+	}
+	electronics, err := s.GetProductsByCategory(1)
+	if err != nil {
+		t.Fatalf("GetProductsByCategory(1): %v", err)
+	}
+	if len(electronics) != 1 || electronics[0].Name != "Laptop" {
+		t.Errorf("category 1 lists %+v, want only the Laptop", electronics)
+	}
+	
+	// The indexes of the other entities are derived again too
+	if ids := indexedIDs(t, s, "orders", "user", "1"); len(ids) != 2 || !containsID(ids, 1) || !containsID(ids, 3) {
+		t.Errorf("user 1 order index holds %v, want orders 1 and 3", ids)
+	}
+	if ids := indexedIDs(t, s, "categories", "name", "Books"); len(ids) != 1 || ids[0] != 2 {
+		t.Errorf("Books name index holds %v, want category 2", ids)
+	}
+}
+
+func TestRebuildIndexesSkipsSoftDeletedRecords(t *testing.T) {
+	s := newTestService(t)
+	
+	if err := s.SoftDelete("orders", 3); err != nil {
+		t.Fatalf("SoftDelete: %v", err)
+	}
+	if err := s.RebuildIndexes(); err != nil {
+		t.Fatalf("RebuildIndexes: %v", err)
+	}
+	
+	if ids := indexedIDs(t, s, "orders", "user", "1"); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("user 1 order index holds %v, want only order 1", ids)
+	}
+}
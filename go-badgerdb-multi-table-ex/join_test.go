@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// newJoinBenchService returns an in-memory service holding the given number
+// of companies, users and orders, spread evenly over 20 products in 5
+// categories
+func newJoinBenchService(b *testing.B, companies, users, orders int) *BadgerService {
+	b.Helper()
+	
+	s, err := NewInMemoryBadgerService()
+	if err != nil {
+		b.Fatalf("NewInMemoryBadgerService: %v", err)
+	}
+	b.Cleanup(func() { s.Close() })
+	s.SetClock(func() time.Time { return testClock })
+	
+	var categoryIDs, productIDs, companyIDs, userIDs []int64
+	for i := 0; i < 5; i++ {
+		category := &Category{Name: fmt.Sprintf("Category %d", i)}
+		if err := s.CreateCategory(category); err != nil {
+			b.Fatalf("CreateCategory: %v", err)
+		}
+		categoryIDs = append(categoryIDs, category.ID)
+	}
+	for i := 0; i < 20; i++ {
+		product := &Product{Name: fmt.Sprintf("Product %d", i), Price: 10, CategoryID: categoryIDs[i%len(categoryIDs)]}
+		if err := s.CreateProduct(product); err != nil {
+			b.Fatalf("CreateProduct: %v", err)
+		}
+		productIDs = append(productIDs, product.ID)
+	}
+	for i := 0; i < companies; i++ {
+		company := &Company{Name: fmt.Sprintf("Company %d", i)}
+		if err := s.CreateCompany(company); err != nil {
+			b.Fatalf("CreateCompany: %v", err)
+		}
+		companyIDs = append(companyIDs, company.ID)
+	}
+	for i := 0; i < users; i++ {
+		user := &User{Name: fmt.Sprintf("User %d", i), Email: fmt.Sprintf("user%d@example.com", i), CompanyID: companyIDs[i%len(companyIDs)]}
+		if err := s.CreateUser(user); err != nil {
+			b.Fatalf("CreateUser: %v", err)
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+	for i := 0; i < orders; i++ {
+		order := &Order{UserID: userIDs[i%len(userIDs)], ProductID: productIDs[i%len(productIDs)], Quantity: 1, Amount: 10, Status: "completed"}
+		if err := s.CreateOrder(order); err != nil {
+			b.Fatalf("CreateOrder: %v", err)
+		}
+	}
+	return s
+}
+
+// usersWithCompaniesPointReads is GetUsersWithCompanies as it was before the
+// companies were preloaded, with one read per user
+func usersWithCompaniesPointReads(s *BadgerService) ([]UserWithCompany, error) {
+	var users []User
+	if err := s.list("users", &users); err != nil {
+		return nil, err
+	}
+	
+	var results []UserWithCompany
+	for _, user := range users {
+		var company Company
+		if err := s.get("companies", user.CompanyID, &company); err != nil {
+			continue
+		}
+		results = append(results, UserWithCompany{User: user, Company: company})
+	}
+	return results, nil
+}
+
+// ordersWithDetailsPointReads is GetOrdersWithDetails as it was before the
+// users, products and categories were preloaded, with three reads per order
+func ordersWithDetailsPointReads(s *BadgerService) ([]OrderWithDetails, error) {
+	var orders []Order
+	if err := s.list("orders", &orders); err != nil {
+		return nil, err
+	}
+	
+	var results []OrderWithDetails
+	for _, order := range orders {
+		var user User
+		if err := s.get("users", order.UserID, &user); err != nil {
+			continue
+		}
+		var product Product
+		if err := s.get("products", order.ProductID, &product); err != nil {
+			continue
+		}
+		var category Category
+		if err := s.get("categories", product.CategoryID, &category); err != nil {
+			continue
+		}
+		results = append(results, OrderWithDetails{Order: order, User: user, Product: product, Category: category})
+	}
+	return results, nil
+}
+
+func BenchmarkGetUsersWithCompanies(b *testing.B) {
+	s := newJoinBenchService(b, 50, 1000, 5000)
+	
+	b.Run("point-reads", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := usersWithCompaniesPointReads(s); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("preloaded", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := s.GetUsersWithCompanies(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkGetOrdersWithDetails(b *testing.B) {
+	s := newJoinBenchService(b, 50, 1000, 5000)
+	
+	b.Run("point-reads", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := ordersWithDetailsPointReads(s); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("preloaded", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := s.GetOrdersWithDetails(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkGetOrdersWithDetailsTransactions reports the read transactions
+// each join opens for 10k orders as txns/op
+func BenchmarkGetOrdersWithDetailsTransactions(b *testing.B) {
+	s := newJoinBenchService(b, 50, 1000, 10000)
+	
+	txns := 0
+	onView = func() { txns++ }
+	b.Cleanup(func() { onView = nil })
+	
+	for _, bc := range []struct {
+		name string
+		join func(*BadgerService) ([]OrderWithDetails, error)
+	}{
+		{"point-reads", ordersWithDetailsPointReads},
+		{"batched", (*BadgerService).GetOrdersWithDetails},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			txns = 0
+			for i := 0; i < b.N; i++ {
+				if _, err := bc.join(s); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(txns)/float64(b.N), "txns/op")
+		})
+	}
+}
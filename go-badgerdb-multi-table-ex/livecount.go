@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Live counts are stored as 8-byte big-endian integers under "live:<entity>",
+// prefixed with the tenant if the service has one. Unlike the ID counters,
+// which only grow, they are adjusted in the transaction that creates or
+// deletes a record, so they always match the number of stored records. They
+// are only maintained with WithCountTracking.
+
+func (s *BadgerService) liveKey(entity string) []byte {
+	return s.scopedKey(s.joinKey("live", entity))
+}
+
+// Count returns the number of records of entity. With count tracking this is
+// a single read, otherwise the entity's keys are counted.
+func (s *BadgerService) Count(entity string) (int64, error) {
+	if _, ok := entityTypes[entity]; !ok {
+		return 0, fmt.Errorf("no record type registered for %s", entity)
+	}
+	
+	if !s.countTracking {
+		count, err := s.countPrefix(s.entityPrefix(entity))
+		return int64(count), err
+	}
+	
+	var count int64
+	err := s.view(func(txn *badger.Txn) error {
+		var err error
+		count, err = readLiveCount(txn, s.liveKey(entity))
+		return err
+	})
+	return count, err
+}
+
+// readLiveCount returns the live count stored at key, 0 if there is none
+func readLiveCount(txn *badger.Txn, key []byte) (int64, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	
+	var count int64
+	err = item.Value(func(val []byte) error {
+		count = decodeCounter(val)
+		return nil
+	})
+	return count, err
+}
+
+// adjustLiveCount adds delta to the live count of entity as part of an
+// existing transaction. The count never drops below zero.
+func (s *BadgerService) adjustLiveCount(txn *badger.Txn, entity string, delta int64) error {
+	if !s.countTracking {
+		return nil
+	}
+	
+	key := s.liveKey(entity)
+	count, err := readLiveCount(txn, key)
+	if err != nil {
+		return err
+	}
+	
+	return txn.Set(key, encodeCounter(max(count+delta, 0)))
+}
+
+// recountLive sets the live count of each entity to the number of its stored
+// records. It is used when tracking starts on existing data and after bulk
+// operations that bypass the per-record bookkeeping.
+func (s *BadgerService) recountLive(entities ...string) error {
+	if !s.countTracking || s.readOnly {
+		return nil
+	}
+	
+	for _, entity := range entities {
+		count, err := s.countPrefix(s.entityPrefix(entity))
+		if err != nil {
+			return err
+		}
+		
+		err = s.updateWithRetry(func(txn *badger.Txn) error {
+			return txn.Set(s.liveKey(entity), encodeCounter(int64(count)))
+		}, defaultMaxRetries)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recountAllLive recounts the records of every entity
+func (s *BadgerService) recountAllLive() error {
+	for entity := range entityTypes {
+		if err := s.recountLive(entity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initLiveCounts counts the records of every entity that has no live count
+// yet, so enabling tracking on an existing database starts out correct
+func (s *BadgerService) initLiveCounts() error {
+	if !s.countTracking || s.readOnly {
+		return nil
+	}
+	
+	var missing []string
+	err := s.view(func(txn *badger.Txn) error {
+		for entity := range entityTypes {
+			_, err := txn.Get(s.liveKey(entity))
+			if err == badger.ErrKeyNotFound {
+				missing = append(missing, entity)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	
+	return s.recountLive(missing...)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// storedLiveCount reads the live count of entity directly, bypassing Count
+func storedLiveCount(t *testing.T, s *BadgerService, entity string) int64 {
+	t.Helper()
+	
+	var count int64
+	err := s.view(func(txn *badger.Txn) error {
+		var err error
+		count, err = readLiveCount(txn, s.liveKey(entity))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("readLiveCount %s: %v", entity, err)
+	}
+	return count
+}
+
+func TestCountTrackingFollowsCreatesAndDeletes(t *testing.T) {
+	s := newTestService(t, WithCountTracking(true))
+	
+	if got := storedLiveCount(t, s, "users"); got != 3 {
+		t.Fatalf("live count of the seeded users = %d, want 3", got)
+	}
+	
+	user := &User{Name: "Dana White", Email: "dana@example.com", CompanyID: 1}
+	if err := s.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if count, err := s.Count("users"); err != nil || count != 4 {
+		t.Errorf("Count after create = %d, %v, want 4", count, err)
+	}
+	
+	if err := s.Users().Delete(user.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if count, err := s.Count("users"); err != nil || count != 3 {
+		t.Errorf("Count after delete = %d, %v, want 3", count, err)
+	}
+	
+	// Deleting the same user again does not count it twice
+	s.Users().Delete(user.ID)
+	if count, err := s.Count("users"); err != nil || count != 3 {
+		t.Errorf("Count after a double delete = %d, %v, want 3", count, err)
+	}
+	
+	// Updates leave the count alone
+	user, err := s.Users().Get(1)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	user.Name = "Alice Jones"
+	if err := s.Users().Update(user); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if count, err := s.Count("users"); err != nil || count != 3 {
+		t.Errorf("Count after update = %d, %v, want 3", count, err)
+	}
+}
+
+func TestCountTrackingNeverGoesNegative(t *testing.T) {
+	s := newTestService(t, WithCountTracking(true))
+	
+	for id := int64(1); id <= 3; id++ {
+		if err := s.Categories().Delete(id); err != nil {
+			t.Fatalf("Delete(%d): %v", id, err)
+		}
+	}
+	s.Categories().Delete(1)
+	
+	if count, err := s.Count("categories"); err != nil || count != 0 {
+		t.Errorf("Count = %d, %v, want 0", count, err)
+	}
+}
+
+func TestCountTrackingStartsFromExistingRecords(t *testing.T) {
+	dir := t.TempDir()
+	
+	s, err := NewBadgerService(dir)
+	if err != nil {
+		t.Fatalf("NewBadgerService: %v", err)
+	}
+	if err := setupTestData(s, false); err != nil {
+		t.Fatalf("setupTestData: %v", err)
+	}
+	s.Close()
+	
+	s, err = NewBadgerService(dir, WithCountTracking(true))
+	if err != nil {
+		t.Fatalf("reopening with count tracking: %v", err)
+	}
+	defer s.Close()
+	
+	if got := storedLiveCount(t, s, "orders"); got != 4 {
+		t.Errorf("live count of orders = %d, want 4", got)
+	}
+}
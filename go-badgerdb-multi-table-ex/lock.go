@@ -0,0 +1,17 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrDatabaseLocked is returned by NewBadgerService when another process,
+// or another service in this one, already has the database open
+var ErrDatabaseLocked = errors.New("database is locked")
+
+// isLockError reports whether err is Badger failing to take the directory
+// lock. Badger formats the underlying error into its message instead of
+// wrapping it, so the message is all there is to go on.
+func isLockError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "Cannot acquire directory lock")
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOpeningALockedDatabase(t *testing.T) {
+	dir := t.TempDir()
+	
+	s, err := NewBadgerService(dir)
+	if err != nil {
+		t.Fatalf("NewBadgerService: %v", err)
+	}
+	
+	second, err := NewBadgerService(dir)
+	if err == nil {
+		second.Close()
+		s.Close()
+		t.Fatal("the same path was opened twice")
+	}
+	if !errors.Is(err, ErrDatabaseLocked) {
+		t.Errorf("second open = %v, want ErrDatabaseLocked", err)
+	}
+	if !strings.Contains(err.Error(), dir) {
+		t.Errorf("error %q does not name the path", err)
+	}
+	
+	// The lock goes away with the service holding it
+	s.Close()
+	s, err = NewBadgerService(dir)
+	if err != nil {
+		t.Fatalf("reopening after Close: %v", err)
+	}
+	s.Close()
+}
@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
 	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dgraph-io/badger/v4"
+
+	"go-baderdb-ex/server"
 )
 
 // User represents a user entity
@@ -28,15 +37,44 @@ type Company struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
-// Order represents an order entity
+// Order represents an order entity. An order either lists its products in
+// LineItems or, as orders written before line items existed do, names a
+// single product with ProductID and Quantity.
 type Order struct {
-	ID        int64     `json:"id"`
-	UserID    int64     `json:"user_id"`
-	ProductID int64     `json:"product_id"`
-	Quantity  int       `json:"quantity"`
-	Amount    float64   `json:"amount"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	ProductID int64      `json:"product_id"`
+	Quantity  int        `json:"quantity"`
+	LineItems []LineItem `json:"line_items,omitempty"`
+	Amount    float64    `json:"amount"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// LineItem is one product of an order
+type LineItem struct {
+	ProductID int64   `json:"product_id"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+}
+
+// Lines returns the line items of the order. An order without LineItems has
+// a single implicit line for ProductID, priced at Amount divided by Quantity.
+func (o *Order) Lines() []LineItem {
+	if len(o.LineItems) > 0 {
+		return o.LineItems
+	}
+	
+	unitPrice := o.Amount
+	if o.Quantity > 0 {
+		unitPrice = o.Amount / float64(o.Quantity)
+	}
+	return []LineItem{{ProductID: o.ProductID, Quantity: o.Quantity, UnitPrice: unitPrice}}
+}
+
+// Total returns the price of the line
+func (li LineItem) Total() float64 {
+	return float64(li.Quantity) * li.UnitPrice
 }
 
 // Product represents a product entity
@@ -61,10 +99,21 @@ type UserWithCompany struct {
 	Company Company `json:"company"`
 }
 
+// OrderWithDetails is an order joined with its user and, per line, the
+// product and its category. Product and Category are those of the first
+// line.
 type OrderWithDetails struct {
-	Order    Order   `json:"order"`
-	User     User    `json:"user"`
-	Product  Product `json:"product"`
+	Order    Order         `json:"order"`
+	User     User          `json:"user"`
+	Product  Product       `json:"product"`
+	Category Category      `json:"category"`
+	Lines    []LineDetails `json:"lines"`
+}
+
+// LineDetails is a line item joined with its product and category
+type LineDetails struct {
+	Item     LineItem `json:"item"`
+	Product  Product  `json:"product"`
 	Category Category `json:"category"`
 }
 
@@ -75,229 +124,988 @@ type CompanyStats struct {
 	TotalRevenue float64 `json:"total_revenue"`
 }
 
+type ProductSales struct {
+	Product     Product `json:"product"`
+	TotalOrders int     `json:"total_orders"`
+	TotalRevenue float64 `json:"total_revenue"`
+}
+
+type CategorySales struct {
+	Category string        `json:"category"`
+	Products []ProductSales `json:"products"`
+}
+
 // BadgerService handles all database operations
 type BadgerService struct {
-	db       *badger.DB
-	counters map[string]int64
-	mu       sync.RWMutex
+	db            *badger.DB
+	tenant        string
+	readOnly      bool
+	foreignKeys   bool
+	countTracking bool
+	sep           byte
+	closed        *atomic.Bool
+	prefetchSize  int
+	idGenerator   IDGenerator
+	existence     *existenceFilters
+	omitZero      bool
+	counters      map[string]int64
+	tenants       *tenantRegistry
+
+	// Guards the ID counters and the stats cache. Views of the same tenant
+	// share it along with the counters, see WithTenant.
+	mu *sync.RWMutex
+
+	// Merge operators incrementing the ID counters, see getNextID
+	counterOps map[string]*badger.MergeOperator
+
+	// Memoized GetCompanyStats, see GetCompanyStatsCached
+	statsCache companyStatsCache
+
+	// Per-entity record validators, see SetValidator. They have their own
+	// lock because WithTransaction holds mu while records are written.
+	validators   map[string]func(json.RawMessage) error
+	validatorsMu sync.RWMutex
+
+	// Background value log GC, see StartGC
+	gcMu   sync.Mutex
+	gcStop chan struct{}
+	gcDone chan struct{}
+
+	// nowFn supplies the CreatedAt and soft delete timestamps, see SetClock
+	nowFn func() time.Time
+
+	// codec encodes the stored records, see WithCodec
+	codec Codec
+
+	// OnOperation, if set, is called after every create, get, update and
+	// delete with how long the operation took and the error it returned. It
+	// is the place to hook in metrics or logging.
+	OnOperation func(op, entity string, id int64, dur time.Duration, err error)
 }
 
-func NewBadgerService(dbPath string) (*BadgerService, error) {
-	opts := badger.DefaultOptions(dbPath)
-	opts.Logger = nil
+func NewBadgerService(dbPath string, opts ...Option) (*BadgerService, error) {
+	o := serviceOptions{codec: JSONCodec{}, sep: ':', idGenerator: SequentialIDGenerator{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
 	
-	db, err := badger.Open(opts)
+	if !validKeySeparator(o.sep) {
+		return nil, fmt.Errorf("invalid key separator %q", o.sep)
+	}
+	
+	switch len(o.encryptionKey) {
+	case 0, 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("encryption key must be 16, 24 or 32 bytes, got %d", len(o.encryptionKey))
+	}
+	
+	db, err := badger.Open(o.badgerOptions(dbPath))
+	if isLockError(err) {
+		return nil, fmt.Errorf("%w: %s is already open, check for another process using it", ErrDatabaseLocked, dbPath)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to open BadgerDB: %w", err)
 	}
 	
 	service := &BadgerService{
-		db:       db,
-		counters: make(map[string]int64),
+		db:            db,
+		readOnly:      o.readOnly,
+		foreignKeys:   o.foreignKeys,
+		countTracking: o.countTracking,
+		sep:           o.sep,
+		closed:        new(atomic.Bool),
+		prefetchSize:  o.prefetchSize,
+		idGenerator:   o.idGenerator,
+		omitZero:      o.omitZero,
+		counters:      make(map[string]int64),
+		tenants:       &tenantRegistry{byTenant: make(map[string]*tenantCounters)},
+		mu:            new(sync.RWMutex),
+		counterOps:    make(map[string]*badger.MergeOperator),
+		validators:    make(map[string]func(json.RawMessage) error),
+		nowFn:         time.Now,
+		codec:         o.codec,
+	}
+	
+	if o.expectedKeys > 0 {
+		service.existence = &existenceFilters{
+			expectedKeys: o.expectedKeys,
+			byTenant:     make(map[string]*bloomFilter),
+		}
+		if _, err := service.existenceFilter(); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to build existence filter: %w", err)
+		}
 	}
 	
 	// Initialize counters
-	service.initCounters()
+	if err := service.initCounters(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load ID counters: %w", err)
+	}
+	if err := service.initLiveCounts(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize live counts: %w", err)
+	}
 	
 	return service, nil
 }
 
-func (s *BadgerService) initCounters() {
+// NewInMemoryBadgerService opens a service backed by an in-memory store, so
+// tests need neither a temp directory nor cleanup
+func NewInMemoryBadgerService(opts ...Option) (*BadgerService, error) {
+	return NewBadgerService("", append(opts, WithInMemory(true))...)
+}
+
+// SetClock replaces the clock used for CreatedAt and soft deletes, so tests
+// can pin timestamps. It must be called before the service is used concurrently.
+func (s *BadgerService) SetClock(now func() time.Time) {
+	s.nowFn = now
+}
+
+// initCounters loads the ID counters, rewriting any still in the legacy JSON
+// format so the merge operators can add to them. A counter that is behind the
+// highest stored ID, e.g. because a partial restore lost its key, is raised
+// to that ID so new records cannot overwrite existing ones. A counter that
+// cannot be read fails with ErrCorruptCounter.
+func (s *BadgerService) initCounters() error {
 	entities := []string{"users", "companies", "orders", "products", "categories"}
 	
 	for _, entity := range entities {
-		s.db.View(func(txn *badger.Txn) error {
-			key := fmt.Sprintf("counter:%s", entity)
-			item, err := txn.Get([]byte(key))
+		var rewrite bool
+		err := s.view(func(txn *badger.Txn) error {
+			counter, legacy, err := readCounter(txn, s.counterKey(entity))
 			if err != nil {
-				s.counters[entity] = 0
-				return nil
+				return err
 			}
 			
-			return item.Value(func(val []byte) error {
-				var counter int64
-				json.Unmarshal(val, &counter)
-				s.counters[entity] = counter
-				return nil
-			})
+			maxID, err := maxStoredID(txn, s.entityPrefix(entity))
+			if err != nil {
+				return err
+			}
+			
+			s.counters[entity] = counter
+			rewrite = legacy
+			if maxID > counter {
+				s.counters[entity] = maxID
+				rewrite = true
+			}
+			return nil
 		})
+		if err != nil {
+			return err
+		}
+		
+		if rewrite && !s.readOnly {
+			err := s.updateWithRetry(func(txn *badger.Txn) error {
+				return setCounterBase(txn, s.counterKey(entity), s.counters[entity])
+			}, defaultMaxRetries)
+			if err != nil {
+				return err
+			}
+		}
 	}
+	return nil
 }
 
-func (s *BadgerService) getNextID(entity string) int64 {
+// getNextID allocates the next ID of entity. The increment is a merge entry,
+// so it is atomic at the storage layer. The new value is not read back from
+// the merge operator, which would re-merge every increment since the last
+// compaction: the lock serializes allocations and Badger's directory lock
+// keeps other processes out, so the in-memory counter always equals the
+// stored one. If the counter cannot be advanced no ID is handed out, since
+// guessing one could repeat an ID the stored counter already gave.
+func (s *BadgerService) getNextID(entity string) (int64, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	
-	s.counters[entity]++
+	if err := s.checkOpen(); err != nil {
+		return 0, err
+	}
 	
-	// Update counter in database
-	s.db.Update(func(txn *badger.Txn) error {
-		key := fmt.Sprintf("counter:%s", entity)
-		data, _ := json.Marshal(s.counters[entity])
-		return txn.Set([]byte(key), data)
-	})
+	if err := s.counterOp(entity).Add(encodeCounter(1)); err != nil {
+		return 0, fmt.Errorf("%w: advancing the %s counter: %v", ErrIDAllocation, entity, err)
+	}
 	
-	return s.counters[entity]
+	s.counters[entity]++
+	return s.counters[entity], nil
 }
 
+// ErrIDAllocation is returned by a create whose record could not be given an
+// ID because the ID counter could not be updated
+var ErrIDAllocation = errors.New("failed to allocate an ID")
+
 // Generic CRUD operations
 func (s *BadgerService) create(entity string, id int64, data interface{}) error {
-	return s.db.Update(func(txn *badger.Txn) error {
-		jsonData, err := json.Marshal(data)
+	start := time.Now()
+	err := s.writeTxn(func(txn *badger.Txn) error {
+		return s.setRecord(txn, entity, id, data)
+	})
+	s.observe("create", entity, id, start, err)
+	if err == nil {
+		s.invalidateStatsFor(entity)
+	}
+	
+	return err
+}
+
+// observe reports a finished operation to OnOperation
+func (s *BadgerService) observe(op, entity string, id int64, start time.Time, err error) {
+	if s.OnOperation != nil {
+		s.OnOperation(op, entity, id, time.Since(start), err)
+	}
+}
+
+// setRecord writes a record as part of an existing transaction
+func (s *BadgerService) setRecord(txn *badger.Txn, entity string, id int64, data interface{}) error {
+	value, err := s.marshalRecord(data)
+	if err != nil {
+		return err
+	}
+	
+	// Validators and the audit log always see JSON
+	jsonData := value
+	if _, ok := s.codec.(JSONCodec); !ok {
+		if jsonData, err = json.Marshal(data); err != nil {
+			return err
+		}
+	}
+	
+	if err := s.validate(entity, jsonData); err != nil {
+		return err
+	}
+	
+	if s.foreignKeys {
+		if err := s.checkForeignKeys(txn, entity, data); err != nil {
+			return err
+		}
+	}
+	
+	key := s.recordKey(entity, id)
+	before, err := s.currentJSON(txn, entity, key)
+	if err != nil {
+		return err
+	}
+	
+	if err := s.updateIndexes(txn, entity, id, key, data); err != nil {
+		return err
+	}
+	
+	op := "update"
+	if before == nil {
+		op = "create"
+		if err := s.adjustLiveCount(txn, entity, 1); err != nil {
+			return err
+		}
+		s.markExists(entity, id)
+	}
+	if err := s.writeAudit(txn, op, entity, id, before, jsonData); err != nil {
+		return err
+	}
+	
+	return txn.Set(key, value)
+}
+
+// currentJSON returns the record stored at key as JSON, or nil if there is none
+func (s *BadgerService) currentJSON(txn *badger.Txn, entity string, key []byte) ([]byte, error) {
+	item, err := txn.Get(key)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.toJSON(entity, val)
+}
+
+func (s *BadgerService) update(entity string, id int64, data interface{}) error {
+	start := time.Now()
+	err := s.writeTxn(func(txn *badger.Txn) error {
+		// Check if record exists
+		_, err := txn.Get(s.recordKey(entity, id))
+		if err == badger.ErrKeyNotFound {
+			return notFound(entity, id, err)
+		}
 		if err != nil {
 			return err
 		}
 		
-		key := fmt.Sprintf("%s:%d", entity, id)
-		return txn.Set([]byte(key), jsonData)
+		return s.setRecord(txn, entity, id, data)
 	})
+	s.observe("update", entity, id, start, err)
+	if err == nil {
+		s.invalidateStatsFor(entity)
+	}
+	
+	return err
+}
+
+func (s *BadgerService) delete(entity string, id int64) error {
+	start := time.Now()
+	var existed bool
+	err := s.writeTxn(func(txn *badger.Txn) error {
+		var err error
+		existed, err = s.deleteRecord(txn, entity, id)
+		return err
+	})
+	s.observe("delete", entity, id, start, err)
+	if err == nil {
+		// Deleting a missing record must not remove anything from the filter,
+		// or a stored record sharing its counters would be missed
+		if existed {
+			s.markDeleted(entity, id)
+		}
+		s.invalidateStatsFor(entity)
+	}
+	
+	return err
+}
+
+// deleteRecord removes a record and its index entries as part of an existing
+// transaction, reporting whether the record was stored
+func (s *BadgerService) deleteRecord(txn *badger.Txn, entity string, id int64) (bool, error) {
+	key := s.recordKey(entity, id)
+	before, err := s.currentJSON(txn, entity, key)
+	if err != nil {
+		return false, err
+	}
+	
+	if err := s.removeIndexes(txn, entity, id, key); err != nil {
+		return false, err
+	}
+	
+	if before != nil {
+		if err := s.adjustLiveCount(txn, entity, -1); err != nil {
+			return false, err
+		}
+		if err := s.writeAudit(txn, "delete", entity, id, before, nil); err != nil {
+			return false, err
+		}
+	}
+	
+	if err := txn.Delete(s.softDeleteKey(entity, id)); err != nil {
+		return false, err
+	}
+	return before != nil, txn.Delete(key)
+}
+
+// ErrNotFound is returned when the record an operation needs does not exist.
+// It wraps badger.ErrKeyNotFound, so checking for either works.
+var ErrNotFound = errors.New("record not found")
+
+// notFound reports that record id of entity does not exist
+func notFound(entity string, id int64, err error) error {
+	return fmt.Errorf("%s %d: %w (%w)", entity, id, ErrNotFound, err)
 }
 
 func (s *BadgerService) get(entity string, id int64, result interface{}) error {
-	return s.db.View(func(txn *badger.Txn) error {
-		key := fmt.Sprintf("%s:%d", entity, id)
-		item, err := txn.Get([]byte(key))
+	start := time.Now()
+	err := s.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(s.recordKey(entity, id))
+		if err == badger.ErrKeyNotFound {
+			return notFound(entity, id, err)
+		}
+		if err != nil {
+			return err
+		}
+		
+		deleted, err := s.isSoftDeleted(txn, entity, id)
 		if err != nil {
 			return err
 		}
+		if deleted {
+			return notFound(entity, id, badger.ErrKeyNotFound)
+		}
 		
 		return item.Value(func(val []byte) error {
-			return json.Unmarshal(val, result)
+			return s.codec.Unmarshal(val, result)
 		})
 	})
+	s.observe("get", entity, id, start, err)
+	
+	return err
+}
+
+// getMany reads several records in a single transaction. result must point to
+// a map[int64]T; IDs that do not exist are simply absent from the map.
+func (s *BadgerService) getMany(entity string, ids []int64, result interface{}) error {
+	return s.view(func(txn *badger.Txn) error {
+		items := make(map[int64][]byte, len(ids))
+		
+		for _, id := range ids {
+			item, err := txn.Get(s.recordKey(entity, id))
+			if err == badger.ErrKeyNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			
+			deleted, err := s.isSoftDeleted(txn, entity, id)
+			if err != nil {
+				return err
+			}
+			if deleted {
+				continue
+			}
+			
+			items[id], err = item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+		}
+		
+		// Convert to the expected map type
+		return s.decodeMap(items, result)
+	})
 }
 
 func (s *BadgerService) list(entity string, result interface{}) error {
-	return s.db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchValues = true
-		it := txn.NewIterator(opts)
-		defer it.Close()
+	return s.view(func(txn *badger.Txn) error {
+		return s.listTxn(txn, entity, result)
+	})
+}
+
+// listTxn reads every record of entity as part of an existing transaction
+func (s *BadgerService) listTxn(txn *badger.Txn, entity string, result interface{}) error {
+	opts := s.iteratorOptions()
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	
+	deleted, err := s.softDeletedIDs(txn, entity)
+	if err != nil {
+		return err
+	}
+	
+	prefix := s.entityPrefix(entity)
+	var items [][]byte
+	
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		if isSoftDeletedKey(deleted, it.Item().Key(), prefix) {
+			continue
+		}
+		
+		val, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		items = append(items, val)
+	}
+	
+	// Convert to the expected slice type
+	return s.decodeSlice(items, result)
+}
+
+// listByIndex reads the records of entity indexed under name=value
+func (s *BadgerService) listByIndex(entity, name, value string, result interface{}) error {
+	return s.view(func(txn *badger.Txn) error {
+		ids, err := s.lookupIndex(txn, entity, name, value)
+		if err != nil {
+			return err
+		}
 		
-		prefix := []byte(fmt.Sprintf("%s:", entity))
-		items := []json.RawMessage{}
+		var items [][]byte
 		
-		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
-			item := it.Item()
-			err := item.Value(func(val []byte) error {
-				items = append(items, json.RawMessage(val))
-				return nil
-			})
+		for _, id := range ids {
+			item, err := txn.Get(s.recordKey(entity, id))
+			if err == badger.ErrKeyNotFound {
+				continue // Stale index entry
+			}
 			if err != nil {
 				return err
 			}
+			
+			deleted, err := s.isSoftDeleted(txn, entity, id)
+			if err != nil {
+				return err
+			}
+			if deleted {
+				continue
+			}
+			
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			items = append(items, val)
 		}
 		
 		// Convert to the expected slice type
-		jsonData, err := json.Marshal(items)
+		return s.decodeSlice(items, result)
+	})
+}
+
+// GetAllMap lists every record of entity and returns them keyed by keyFn,
+// which saves joins from building their lookup tables by hand.
+func GetAllMap[T any](s *BadgerService, entity string, keyFn func(T) int64) (map[int64]T, error) {
+	var items []T
+	if err := s.list(entity, &items); err != nil {
+		return nil, err
+	}
+	
+	result := make(map[int64]T, len(items))
+	for _, item := range items {
+		result[keyFn(item)] = item
+	}
+	
+	return result, nil
+}
+
+// DistinctStringField returns the sorted, unique values of jsonField across
+// the records of entity, e.g. every industry of the companies. Records
+// without the field, or where it is not a string, are skipped, as are
+// soft-deleted records.
+func (s *BadgerService) DistinctStringField(entity, jsonField string) ([]string, error) {
+	seen := make(map[string]bool)
+	
+	err := s.view(func(txn *badger.Txn) error {
+		deleted, err := s.softDeletedIDs(txn, entity)
 		if err != nil {
 			return err
 		}
 		
-		return json.Unmarshal(jsonData, result)
+		it := txn.NewIterator(s.iteratorOptions())
+		defer it.Close()
+		
+		prefix := s.entityPrefix(entity)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			if isSoftDeletedKey(deleted, it.Item().Key(), prefix) {
+				continue
+			}
+			
+			val, err := it.Item().ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			
+			jsonVal, err := s.toJSON(entity, val)
+			if err != nil {
+				return err
+			}
+			
+			var record map[string]interface{}
+			if err := json.Unmarshal(jsonVal, &record); err != nil {
+				return err
+			}
+			
+			if value, ok := record[jsonField].(string); ok {
+				seen[value] = true
+			}
+		}
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	
+	values := make([]string, 0, len(seen))
+	for value := range seen {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	
+	return values, nil
 }
 
-// Entity-specific operations
+// Entity-specific operations, thin wrappers around the repositories
 func (s *BadgerService) CreateUser(user *User) error {
-	user.ID = s.getNextID("users")
-	user.CreatedAt = time.Now()
-	return s.create("users", user.ID, user)
+	user.CreatedAt = s.nowFn()
+	return s.Users().Create(user)
 }
 
 func (s *BadgerService) CreateCompany(company *Company) error {
-	company.ID = s.getNextID("companies")
-	company.CreatedAt = time.Now()
-	return s.create("companies", company.ID, company)
+	company.CreatedAt = s.nowFn()
+	return s.Companies().Create(company)
 }
 
 func (s *BadgerService) CreateOrder(order *Order) error {
-	order.ID = s.getNextID("orders")
-	order.CreatedAt = time.Now()
-	return s.create("orders", order.ID, order)
+	order.CreatedAt = s.nowFn()
+	return s.Orders().Create(order)
+}
+
+func (s *BadgerService) UpdateOrder(order *Order) error {
+	return s.Orders().Update(order)
+}
+
+func (s *BadgerService) DeleteOrder(id int64) error {
+	return s.Orders().Delete(id)
 }
 
 func (s *BadgerService) CreateProduct(product *Product) error {
-	product.ID = s.getNextID("products")
-	return s.create("products", product.ID, product)
+	return s.Products().Create(product)
+}
+
+func (s *BadgerService) UpdateProduct(product *Product) error {
+	return s.Products().Update(product)
+}
+
+func (s *BadgerService) DeleteProduct(id int64) error {
+	return s.Products().Delete(id)
+}
+
+// GetProductsByCategory returns the products of one category using the category index
+func (s *BadgerService) GetProductsByCategory(categoryID int64) ([]Product, error) {
+	var products []Product
+	err := s.listByIndex("products", "category", strconv.FormatInt(categoryID, 10), &products)
+	if err != nil {
+		return nil, err
+	}
+	
+	return products, nil
 }
 
 func (s *BadgerService) CreateCategory(category *Category) error {
-	category.ID = s.getNextID("categories")
-	return s.create("categories", category.ID, category)
+	return s.Categories().Create(category)
+}
+
+// GetOrCreateCategoryByName returns the category called name, creating it if
+// it does not exist yet. The lookup and the create share one transaction that
+// holds the counter lock, so concurrent callers never create duplicates.
+func (s *BadgerService) GetOrCreateCategoryByName(name string) (*Category, error) {
+	var category *Category
+	
+	err := s.WithTransaction(func(tx *Tx) error {
+		ids, err := s.lookupIndex(tx.txn, "categories", "name", name)
+		if err != nil {
+			return err
+		}
+		
+		for _, id := range ids {
+			item, err := tx.txn.Get(s.recordKey("categories", id))
+			if err == badger.ErrKeyNotFound {
+				continue // Stale index entry
+			}
+			if err != nil {
+				return err
+			}
+			
+			deleted, err := s.isSoftDeleted(tx.txn, "categories", id)
+			if err != nil {
+				return err
+			}
+			if deleted {
+				continue
+			}
+			
+			category = &Category{}
+			return item.Value(func(val []byte) error {
+				return tx.s.codec.Unmarshal(val, category)
+			})
+		}
+		
+		category = &Category{Name: name}
+		return tx.CreateCategory(category)
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	return category, nil
+}
+
+// Join-like operations
+
+// 1. Simple 1:1 Join - Users with their Companies
+func (s *BadgerService) GetUsersWithCompanies() ([]UserWithCompany, error) {
+	var users []User
+	err := s.list("users", &users)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Load all companies once and join in memory instead of a point read per user
+	var companies []Company
+	err = s.list("companies", &companies)
+	if err != nil {
+		return nil, err
+	}
+	
+	companyMap := make(map[int64]Company, len(companies))
+	for _, company := range companies {
+		companyMap[company.ID] = company
+	}
+	
+	var results []UserWithCompany
+	
+	for _, user := range users {
+		company, exists := companyMap[user.CompanyID]
+		if !exists {
+			continue // Skip if company not found
+		}
+		
+		results = append(results, UserWithCompany{
+			User:    user,
+			Company: company,
+		})
+	}
+	
+	return results, nil
 }
 
-// Join-like operations
-
-// 1. Simple 1:1 Join - Users with their Companies
-func (s *BadgerService) GetUsersWithCompanies() ([]UserWithCompany, error) {
+// 1b. Left Join - every user, with a zero Company when theirs does not exist
+func (s *BadgerService) GetUsersWithCompaniesLeft() ([]UserWithCompany, error) {
 	var users []User
 	err := s.list("users", &users)
 	if err != nil {
 		return nil, err
 	}
 	
-	var results []UserWithCompany
-	
+	var companyIDs []int64
+	seen := make(map[int64]bool)
 	for _, user := range users {
-		var company Company
-		err := s.get("companies", user.CompanyID, &company)
-		if err != nil {
-			continue // Skip if company not found
+		if !seen[user.CompanyID] {
+			seen[user.CompanyID] = true
+			companyIDs = append(companyIDs, user.CompanyID)
 		}
-		
+	}
+	
+	// getMany only skips missing keys, any other read error is returned
+	companies := make(map[int64]Company)
+	err = s.getMany("companies", companyIDs, &companies)
+	if err != nil {
+		return nil, err
+	}
+	
+	results := make([]UserWithCompany, 0, len(users))
+	for _, user := range users {
 		results = append(results, UserWithCompany{
 			User:    user,
-			Company: company,
+			Company: companies[user.CompanyID],
 		})
 	}
 	
 	return results, nil
 }
 
+// 1c. GetUsersWithCompanies with the company lookups spread over workers
+// goroutines. Each worker reads through its own transaction, since a Badger
+// transaction must not be shared between goroutines. Users whose company does
+// not exist are skipped and the result is sorted by user ID.
+func (s *BadgerService) GetUsersWithCompaniesConcurrent(workers int) ([]UserWithCompany, error) {
+	// The workers open their own transactions instead of going through view
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+	
+	if workers < 1 {
+		workers = 1
+	}
+	
+	var users []User
+	if err := s.list("users", &users); err != nil {
+		return nil, err
+	}
+	
+	jobs := make(chan User)
+	var (
+		mu       sync.Mutex
+		results  []UserWithCompany
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			
+			txn := s.db.NewTransaction(false)
+			defer txn.Discard()
+			
+			companies := make(map[int64]*Company) // nil marks a missing company
+			for user := range jobs {
+				company, seen := companies[user.CompanyID]
+				if !seen {
+					var err error
+					company, err = s.readCompany(txn, user.CompanyID)
+					if err != nil {
+						mu.Lock()
+						if firstErr == nil {
+							firstErr = err
+						}
+						mu.Unlock()
+						continue
+					}
+					companies[user.CompanyID] = company
+				}
+				if company == nil {
+					continue
+				}
+				
+				mu.Lock()
+				results = append(results, UserWithCompany{User: user, Company: *company})
+				mu.Unlock()
+			}
+		}()
+	}
+	
+	for _, user := range users {
+		jobs <- user
+	}
+	close(jobs)
+	wg.Wait()
+	
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	
+	sort.Slice(results, func(i, j int) bool { return results[i].User.ID < results[j].User.ID })
+	return results, nil
+}
+
+// readCompany reads a company within txn, returning nil if it does not exist
+func (s *BadgerService) readCompany(txn *badger.Txn, id int64) (*Company, error) {
+	item, err := txn.Get(s.recordKey("companies", id))
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	deleted, err := s.isSoftDeleted(txn, "companies", id)
+	if deleted || err != nil {
+		return nil, err
+	}
+	
+	company := &Company{}
+	err = item.Value(func(val []byte) error {
+		return s.codec.Unmarshal(val, company)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return company, nil
+}
+
 // 2. Complex Multi-table Join - Orders with User, Product, and Category details
 func (s *BadgerService) GetOrdersWithDetails() ([]OrderWithDetails, error) {
+	results, _, err := s.GetOrdersWithDetailsAndIndex()
+	return results, err
+}
+
+// OrderDetailsIndex holds the lookup maps built while joining the orders, with
+// every user, product and category the orders reference keyed by ID
+type OrderDetailsIndex struct {
+	Users      map[int64]User
+	Products   map[int64]Product
+	Categories map[int64]Category
+}
+
+// 2b. GetOrdersWithDetails that also returns the lookup maps of the join, so
+// callers can work with the referenced users, products and categories
+// without reading them again
+func (s *BadgerService) GetOrdersWithDetailsAndIndex() ([]OrderWithDetails, OrderDetailsIndex, error) {
 	var orders []Order
 	err := s.list("orders", &orders)
 	if err != nil {
-		return nil, err
+		return nil, OrderDetailsIndex{}, err
+	}
+	
+	// Collect the distinct IDs first so each entity is read in one batch
+	var userIDs, productIDs []int64
+	seenUsers := make(map[int64]bool)
+	seenProducts := make(map[int64]bool)
+	for i := range orders {
+		if !seenUsers[orders[i].UserID] {
+			seenUsers[orders[i].UserID] = true
+			userIDs = append(userIDs, orders[i].UserID)
+		}
+		for _, item := range orders[i].Lines() {
+			if !seenProducts[item.ProductID] {
+				seenProducts[item.ProductID] = true
+				productIDs = append(productIDs, item.ProductID)
+			}
+		}
+	}
+	
+	index := OrderDetailsIndex{
+		Users:      make(map[int64]User),
+		Products:   make(map[int64]Product),
+		Categories: make(map[int64]Category),
+	}
+	if err := s.getMany("users", userIDs, &index.Users); err != nil {
+		return nil, OrderDetailsIndex{}, err
+	}
+	
+	if err := s.getMany("products", productIDs, &index.Products); err != nil {
+		return nil, OrderDetailsIndex{}, err
+	}
+	
+	var categoryIDs []int64
+	seenCategories := make(map[int64]bool)
+	for _, product := range index.Products {
+		if !seenCategories[product.CategoryID] {
+			seenCategories[product.CategoryID] = true
+			categoryIDs = append(categoryIDs, product.CategoryID)
+		}
+	}
+	
+	if err := s.getMany("categories", categoryIDs, &index.Categories); err != nil {
+		return nil, OrderDetailsIndex{}, err
 	}
 	
 	var results []OrderWithDetails
 	
 	for _, order := range orders {
-		var user User
-		var product Product
-		var category Category
-		
-		// Get user
-		if err := s.get("users", order.UserID, &user); err != nil {
-			continue
-		}
-		
-		// Get product
-		if err := s.get("products", order.ProductID, &product); err != nil {
+		user, exists := index.Users[order.UserID]
+		if !exists {
 			continue
 		}
 		
-		// Get category
-		if err := s.get("categories", product.CategoryID, &category); err != nil {
+		lines, ok := joinLines(&order, index.Products, index.Categories)
+		if !ok {
 			continue
 		}
 		
 		results = append(results, OrderWithDetails{
 			Order:    order,
 			User:     user,
-			Product:  product,
-			Category: category,
+			Product:  lines[0].Product,
+			Category: lines[0].Category,
+			Lines:    lines,
 		})
 	}
 	
-	return results, nil
+	return results, index, nil
+}
+
+// joinLines resolves the product and category of every line of order. It
+// reports false if any of them is missing, in which case the order is left
+// out of the join.
+func joinLines(order *Order, products map[int64]Product, categories map[int64]Category) ([]LineDetails, bool) {
+	items := order.Lines()
+	lines := make([]LineDetails, 0, len(items))
+	
+	for _, item := range items {
+		product, exists := products[item.ProductID]
+		if !exists {
+			return nil, false
+		}
+		
+		category, exists := categories[product.CategoryID]
+		if !exists {
+			return nil, false
+		}
+		
+		lines = append(lines, LineDetails{Item: item, Product: product, Category: category})
+	}
+	
+	return lines, true
 }
 
 // 3. Aggregation with Grouping - Company statistics
 func (s *BadgerService) GetCompanyStats() ([]CompanyStats, error) {
+	return s.GetCompanyStatsByStatus("")
+}
+
+// GetCompanyStatsByStatus computes company statistics counting only orders
+// with the given status. An empty status counts all orders.
+func (s *BadgerService) GetCompanyStatsByStatus(status string) ([]CompanyStats, error) {
 	var companies []Company
 	err := s.list("companies", &companies)
 	if err != nil {
@@ -316,6 +1124,36 @@ func (s *BadgerService) GetCompanyStats() ([]CompanyStats, error) {
 		return nil, err
 	}
 	
+	return companyStats(companies, users, orders, status), nil
+}
+
+// GetCompanyStatsConsistent is GetCompanyStats with the companies, users and
+// orders all read in one transaction, so writes made while it runs cannot
+// leave the aggregate mixing two states of the database
+func (s *BadgerService) GetCompanyStatsConsistent() ([]CompanyStats, error) {
+	var companies []Company
+	var users []User
+	var orders []Order
+	
+	err := s.view(func(txn *badger.Txn) error {
+		if err := s.listTxn(txn, "companies", &companies); err != nil {
+			return err
+		}
+		if err := s.listTxn(txn, "users", &users); err != nil {
+			return err
+		}
+		return s.listTxn(txn, "orders", &orders)
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	return companyStats(companies, users, orders, ""), nil
+}
+
+// companyStats aggregates users and orders per company, counting only orders
+// with the given status unless status is empty
+func companyStats(companies []Company, users []User, orders []Order, status string) []CompanyStats {
 	// Group users by company
 	usersByCompany := make(map[int64][]User)
 	for _, user := range users {
@@ -325,6 +1163,10 @@ func (s *BadgerService) GetCompanyStats() ([]CompanyStats, error) {
 	// Group orders by company (through users)
 	ordersByCompany := make(map[int64][]Order)
 	for _, order := range orders {
+		if status != "" && order.Status != status {
+			continue
+		}
+		
 		for _, user := range users {
 			if user.ID == order.UserID {
 				ordersByCompany[user.CompanyID] = append(ordersByCompany[user.CompanyID], order)
@@ -351,147 +1193,272 @@ func (s *BadgerService) GetCompanyStats() ([]CompanyStats, error) {
 		results = append(results, stats)
 	}
 	
-	return results, nil
+	return results
 }
 
-// 4. Filtered Join - Get orders for a specific user with product details
-func (s *BadgerService) GetUserOrdersWithProducts(userID int64) ([]OrderWithDetails, error) {
+// GetAverageOrderAmountPerUser returns each user's mean order amount. Users
+// without orders are omitted.
+func (s *BadgerService) GetAverageOrderAmountPerUser() (map[int64]float64, error) {
+	return s.GetAverageOrderAmounts(false)
+}
+
+// GetAverageOrderAmounts is GetAverageOrderAmountPerUser, optionally listing
+// users without orders with an average of 0
+func (s *BadgerService) GetAverageOrderAmounts(includeUsersWithoutOrders bool) (map[int64]float64, error) {
 	var orders []Order
 	err := s.list("orders", &orders)
 	if err != nil {
 		return nil, err
 	}
 	
-	var results []OrderWithDetails
+	// Single pass keeping a running sum and count per user
+	type total struct {
+		sum   float64
+		count int
+	}
+	totals := make(map[int64]total)
+	for _, order := range orders {
+		t := totals[order.UserID]
+		t.sum += order.Amount
+		t.count++
+		totals[order.UserID] = t
+	}
+	
+	averages := make(map[int64]float64, len(totals))
+	for userID, t := range totals {
+		averages[userID] = t.sum / float64(t.count)
+	}
+	
+	if includeUsersWithoutOrders {
+		var users []User
+		err = s.list("users", &users)
+		if err != nil {
+			return nil, err
+		}
+		
+		for _, user := range users {
+			if _, ok := averages[user.ID]; !ok {
+				averages[user.ID] = 0
+			}
+		}
+	}
+	
+	return averages, nil
+}
+
+// GetOrdersBetween returns the orders created within [from, to], both bounds
+// inclusive
+func (s *BadgerService) GetOrdersBetween(from, to time.Time) ([]Order, error) {
+	if from.After(to) {
+		return nil, fmt.Errorf("invalid range: from %s is after to %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	
+	var results []Order
+	err := s.Query("orders").
+		Where(func(o Order) bool { return !o.CreatedAt.Before(from) && !o.CreatedAt.After(to) }).
+		All(&results)
+	if err != nil {
+		return nil, err
+	}
 	
+	return results, nil
+}
+
+// 4. Filtered Join - Get orders for a specific user with product details
+func (s *BadgerService) GetUserOrdersWithProducts(userID int64) ([]OrderWithDetails, error) {
 	// Get user once
 	var user User
-	if err := s.get("users", userID, &user); err != nil {
+	err := s.get("users", userID, &user)
+	if errors.Is(err, ErrNotFound) {
 		return nil, fmt.Errorf("user not found: %w", err)
 	}
+	if err != nil {
+		return nil, err
+	}
 	
-	for _, order := range orders {
-		if order.UserID != userID {
-			continue
-		}
-		
-		var product Product
-		var category Category
-		
-		// Get product
-		if err := s.get("products", order.ProductID, &product); err != nil {
-			continue
+	// Read only this user's orders through the user index
+	var orders []Order
+	err = s.listByIndex("orders", "user", strconv.FormatInt(userID, 10), &orders)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Batch the product and category reads instead of two gets per order
+	productIDs := make([]int64, 0, len(orders))
+	for i := range orders {
+		for _, item := range orders[i].Lines() {
+			productIDs = append(productIDs, item.ProductID)
 		}
-		
-		// Get category
-		if err := s.get("categories", product.CategoryID, &category); err != nil {
+	}
+	
+	products := make(map[int64]Product)
+	if err := s.getMany("products", productIDs, &products); err != nil {
+		return nil, err
+	}
+	
+	categoryIDs := make([]int64, 0, len(products))
+	for _, product := range products {
+		categoryIDs = append(categoryIDs, product.CategoryID)
+	}
+	
+	categories := make(map[int64]Category)
+	if err := s.getMany("categories", categoryIDs, &categories); err != nil {
+		return nil, err
+	}
+	
+	var results []OrderWithDetails
+	
+	for _, order := range orders {
+		lines, ok := joinLines(&order, products, categories)
+		if !ok {
 			continue
 		}
 		
 		results = append(results, OrderWithDetails{
 			Order:    order,
 			User:     user,
-			Product:  product,
-			Category: category,
+			Product:  lines[0].Product,
+			Category: lines[0].Category,
+			Lines:    lines,
 		})
 	}
 	
 	return results, nil
 }
 
-// 5. Advanced query - Top selling products by category
-func (s *BadgerService) GetTopSellingProductsByCategory() (map[string][]struct {
-	Product     Product `json:"product"`
-	TotalOrders int     `json:"total_orders"`
-	TotalRevenue float64 `json:"total_revenue"`
-}, error) {
+// 5. Advanced query - Top selling products by category. Only the topN best
+// sellers of each category are kept, 0 keeps them all. Categories are sorted
+// by name.
+func (s *BadgerService) GetTopSellingProductsByCategory(topN int) ([]CategorySales, error) {
 	var orders []Order
 	err := s.list("orders", &orders)
 	if err != nil {
 		return nil, err
 	}
 	
-	var products []Product
-	err = s.list("products", &products)
+	// Create lookup maps
+	productMap, err := GetAllMap(s, "products", func(p Product) int64 { return p.ID })
 	if err != nil {
 		return nil, err
 	}
 	
-	var categories []Category
-	err = s.list("categories", &categories)
+	categoryMap, err := GetAllMap(s, "categories", func(c Category) int64 { return c.ID })
 	if err != nil {
 		return nil, err
 	}
 	
-	// Create lookup maps
-	productMap := make(map[int64]Product)
-	for _, product := range products {
-		productMap[product.ID] = product
-	}
-	
-	categoryMap := make(map[int64]Category)
-	for _, category := range categories {
-		categoryMap[category.ID] = category
-	}
-	
+	return topSellingByCategory(orders, productMap, categoryMap, topN), nil
+}
+
+// topSellingByCategory ranks the products sold in orders by revenue within
+// each category, keeping the topN best of each unless topN is 0
+func topSellingByCategory(orders []Order, productMap map[int64]Product, categoryMap map[int64]Category, topN int) []CategorySales {
 	// Aggregate orders by product
-	productStats := make(map[int64]struct {
-		Product      Product
-		TotalOrders  int
-		TotalRevenue float64
-	})
+	productStats := make(map[int64]ProductSales)
 	
-	for _, order := range orders {
-		product, exists := productMap[order.ProductID]
-		if !exists {
-			continue
+	for i := range orders {
+		for _, item := range orders[i].Lines() {
+			product, exists := productMap[item.ProductID]
+			if !exists {
+				continue
+			}
+			
+			stats := productStats[product.ID]
+			stats.Product = product
+			stats.TotalOrders++
+			stats.TotalRevenue += item.Total()
+			productStats[product.ID] = stats
 		}
-		
-		stats := productStats[product.ID]
-		stats.Product = product
-		stats.TotalOrders++
-		stats.TotalRevenue += order.Amount
-		productStats[product.ID] = stats
 	}
 	
 	// Group by category
-	result := make(map[string][]struct {
-		Product     Product `json:"product"`
-		TotalOrders int     `json:"total_orders"`
-		TotalRevenue float64 `json:"total_revenue"`
-	})
-	
+	byCategory := make(map[string][]ProductSales)
 	for _, stats := range productStats {
-		category := categoryMap[stats.Product.CategoryID]
-		categoryName := category.Name
-		
-		result[categoryName] = append(result[categoryName], struct {
-			Product     Product `json:"product"`
-			TotalOrders int     `json:"total_orders"`
-			TotalRevenue float64 `json:"total_revenue"`
-		}{
-			Product:     stats.Product,
-			TotalOrders: stats.TotalOrders,
-			TotalRevenue: stats.TotalRevenue,
-		})
+		categoryName := categoryMap[stats.Product.CategoryID].Name
+		byCategory[categoryName] = append(byCategory[categoryName], stats)
 	}
 	
-	// Sort by total revenue within each category
-	for categoryName := range result {
-		sort.Slice(result[categoryName], func(i, j int) bool {
-			return result[categoryName][i].TotalRevenue > result[categoryName][j].TotalRevenue
+	result := make([]CategorySales, 0, len(byCategory))
+	for categoryName, products := range byCategory {
+		// Sort by total revenue within each category
+		sort.Slice(products, func(i, j int) bool {
+			return products[i].TotalRevenue > products[j].TotalRevenue
+		})
+		if topN > 0 && len(products) > topN {
+			products = products[:topN]
+		}
+		
+		result = append(result, CategorySales{
+			Category: categoryName,
+			Products: products,
 		})
 	}
 	
-	return result, nil
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Category < result[j].Category
+	})
+	
+	return result
+}
+
+// Ping confirms the store is open and serving reads, for readiness probes.
+// It returns ErrServiceClosed, which wraps badger.ErrDBClosed, once the
+// service has been closed.
+func (s *BadgerService) Ping() error {
+	return s.view(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte("ping"))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		return err
+	})
 }
 
+// Close closes the database, after which every method fails with
+// ErrServiceClosed; closing again does nothing. On a tenant view it only
+// stops the tenant's counters, see WithTenant, while closing the service the
+// view came from closes the view too and stops the counters of every tenant.
 func (s *BadgerService) Close() error {
+	if s.tenant == "" && !s.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	
+	s.StopGC()
+	if s.tenant != "" {
+		s.stopCounterOps()
+		return nil
+	}
+	
+	s.stopAllCounterOps()
 	return s.db.Close()
 }
 
 // Demo functions
-func setupTestData(service *BadgerService) {
+
+// seedEntities lists the entities dropped before re-seeding the demo data
+var seedEntities = []string{"orders", "products", "users", "companies", "categories"}
+
+// setupTestData seeds the demo dataset. Seeding is skipped when users already
+// exist so restarts don't duplicate the data, unless force is set, in which
+// case the demo entities are dropped and seeded again.
+func setupTestData(service *BadgerService, force bool) error {
+	service.mu.RLock()
+	seeded := service.counters["users"] > 0
+	service.mu.RUnlock()
+	
+	if seeded {
+		if !force {
+			log.Println("Test data already present, skipping seeding")
+			return nil
+		}
+		
+		for _, entity := range seedEntities {
+			if _, err := service.DeletePrefix(entity + string(service.sep)); err != nil {
+				return fmt.Errorf("failed to drop %s: %w", entity, err)
+			}
+		}
+	}
+	
 	// Create categories
 	categories := []Category{
 		{Name: "Electronics"},
@@ -499,8 +1466,11 @@ func setupTestData(service *BadgerService) {
 		{Name: "Clothing"},
 	}
 	
-	for _, category := range categories {
-		service.CreateCategory(&category)
+	for i := range categories {
+		category := &categories[i]
+		if err := service.CreateCategory(category); err != nil {
+			return fmt.Errorf("failed to create category %s: %w", category.Name, err)
+		}
 	}
 	
 	// Create companies
@@ -510,46 +1480,66 @@ func setupTestData(service *BadgerService) {
 		{Name: "Book Store Inc", Industry: "Retail"},
 	}
 	
-	for _, company := range companies {
-		service.CreateCompany(&company)
+	for i := range companies {
+		company := &companies[i]
+		if err := service.CreateCompany(company); err != nil {
+			return fmt.Errorf("failed to create company %s: %w", company.Name, err)
+		}
 	}
 	
-	// Create users
+	// Create users, referencing the IDs assigned above rather than
+	// assuming the counters start at 1.
 	users := []User{
-		{Name: "Alice Smith", Email: "alice@example.com", CompanyID: 1},
-		{Name: "Bob Johnson", Email: "bob@example.com", CompanyID: 2},
-		{Name: "Charlie Brown", Email: "charlie@example.com", CompanyID: 1},
+		{Name: "Alice Smith", Email: "alice@example.com", CompanyID: companies[0].ID},
+		{Name: "Bob Johnson", Email: "bob@example.com", CompanyID: companies[1].ID},
+		{Name: "Charlie Brown", Email: "charlie@example.com", CompanyID: companies[0].ID},
 	}
 	
-	for _, user := range users {
-		service.CreateUser(&user)
+	for i := range users {
+		user := &users[i]
+		if err := service.CreateUser(user); err != nil {
+			return fmt.Errorf("failed to create user %s: %w", user.Name, err)
+		}
 	}
 	
 	// Create products
 	products := []Product{
-		{Name: "Laptop", Price: 999.99, CategoryID: 1, CompanyID: 1, Description: "High-performance laptop"},
-		{Name: "Programming Book", Price: 49.99, CategoryID: 2, CompanyID: 3, Description: "Learn Go programming"},
-		{Name: "T-Shirt", Price: 19.99, CategoryID: 3, CompanyID: 2, Description: "Cotton t-shirt"},
+		{Name: "Laptop", Price: 999.99, CategoryID: categories[0].ID, CompanyID: companies[0].ID, Description: "High-performance laptop"},
+		{Name: "Programming Book", Price: 49.99, CategoryID: categories[1].ID, CompanyID: companies[2].ID, Description: "Learn Go programming"},
+		{Name: "T-Shirt", Price: 19.99, CategoryID: categories[2].ID, CompanyID: companies[1].ID, Description: "Cotton t-shirt"},
 	}
 	
-	for _, product := range products {
-		service.CreateProduct(&product)
+	for i := range products {
+		product := &products[i]
+		if err := service.CreateProduct(product); err != nil {
+			return fmt.Errorf("failed to create product %s: %w", product.Name, err)
+		}
 	}
 	
 	// Create orders
 	orders := []Order{
-		{UserID: 1, ProductID: 1, Quantity: 1, Amount: 999.99, Status: "completed"},
-		{UserID: 2, ProductID: 3, Quantity: 2, Amount: 39.98, Status: "completed"},
-		{UserID: 1, ProductID: 2, Quantity: 1, Amount: 49.99, Status: "pending"},
-		{UserID: 3, ProductID: 1, Quantity: 1, Amount: 999.99, Status: "completed"},
+		{UserID: users[0].ID, ProductID: products[0].ID, Quantity: 1, Amount: 999.99, Status: "completed"},
+		{UserID: users[1].ID, ProductID: products[2].ID, Quantity: 2, Amount: 39.98, Status: "completed"},
+		{UserID: users[0].ID, ProductID: products[1].ID, Quantity: 1, Amount: 49.99, Status: "pending"},
+		{UserID: users[2].ID, ProductID: products[0].ID, Quantity: 1, Amount: 999.99, Status: "completed"},
 	}
 	
-	for _, order := range orders {
-		service.CreateOrder(&order)
+	for i := range orders {
+		order := &orders[i]
+		if err := service.CreateOrder(order); err != nil {
+			return fmt.Errorf("failed to create order for user %d: %w", order.UserID, err)
+		}
 	}
+	
+	return nil
 }
 
 func main() {
+	reseed := flag.Bool("reseed", false, "drop existing data and seed the demo data again")
+	httpAddr := flag.String("http", "", "after the demos, serve the API on this address, e.g. :8080")
+	grpcAddr := flag.String("grpc", "", "after the demos, serve the gRPC API on this address, e.g. :9090")
+	flag.Parse()
+	
 	service, err := NewBadgerService("./multi_table_data")
 	if err != nil {
 		log.Fatal(err)
@@ -558,7 +1548,9 @@ func main() {
 	
 	// Setup test data
 	log.Println("Setting up test data...")
-	setupTestData(service)
+	if err := setupTestData(service, *reseed); err != nil {
+		log.Fatalf("Failed to set up test data: %v", err)
+	}
 	
 	// Demo 1: Users with Companies
 	log.Println("\n=== Users with Companies ===")
@@ -595,6 +1587,17 @@ func main() {
 		}
 	}
 	
+	log.Println("\n=== Company Statistics (completed orders only) ===")
+	completedStats, err := service.GetCompanyStatsByStatus("completed")
+	if err != nil {
+		log.Printf("Error: %v", err)
+	} else {
+		for _, stats := range completedStats {
+			log.Printf("Company: %s | Orders: %d | Revenue: $%.2f",
+				stats.Company.Name, stats.OrderCount, stats.TotalRevenue)
+		}
+	}
+	
 	// Demo 4: User-specific orders
 	log.Println("\n=== Alice's Orders ===")
 	aliceOrders, err := service.GetUserOrdersWithProducts(1)
@@ -609,16 +1612,46 @@ func main() {
 	
 	// Demo 5: Top selling products by category
 	log.Println("\n=== Top Selling Products by Category ===")
-	topProducts, err := service.GetTopSellingProductsByCategory()
+	topProducts, err := service.GetTopSellingProductsByCategory(3)
 	if err != nil {
 		log.Printf("Error: %v", err)
 	} else {
-		for categoryName, products := range topProducts {
-			log.Printf("Category: %s", categoryName)
-			for _, product := range products {
+		for _, category := range topProducts {
+			log.Printf("Category: %s", category.Category)
+			for _, product := range category.Products {
 				log.Printf("  - %s: %d orders, $%.2f revenue",
 					product.Product.Name, product.TotalOrders, product.TotalRevenue)
 			}
 		}
 	}
+	
+	if *httpAddr == "" && *grpcAddr == "" {
+		return
+	}
+	
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	
+	var wg sync.WaitGroup
+	if *httpAddr != "" {
+		log.Printf("Serving the API on %s, press Ctrl+C to stop", *httpAddr)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := server.ListenAndServe(ctx, *httpAddr, newHTTPStore(service)); err != nil {
+				log.Printf("Server error: %v", err)
+			}
+		}()
+	}
+	if *grpcAddr != "" {
+		log.Printf("Serving the gRPC API on %s, press Ctrl+C to stop", *grpcAddr)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ServeGRPC(ctx, *grpcAddr, service); err != nil {
+				log.Printf("gRPC server error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
 }
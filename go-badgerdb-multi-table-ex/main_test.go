@@ -0,0 +1,839 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testClock is the time every record seeded by newTestService is created at
+var testClock = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// newTestService returns an in-memory service seeded with the demo data of
+// setupTestData, closed when the test ends
+func newTestService(t *testing.T, opts ...Option) *BadgerService {
+	t.Helper()
+	
+	s, err := NewInMemoryBadgerService(opts...)
+	if err != nil {
+		t.Fatalf("NewInMemoryBadgerService: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	
+	s.SetClock(func() time.Time { return testClock })
+	if err := setupTestData(s, false); err != nil {
+		t.Fatalf("setupTestData: %v", err)
+	}
+	return s
+}
+
+// almostEqual compares amounts, which are sums of floats
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestGetUsersWithCompanies(t *testing.T) {
+	s := newTestService(t)
+	
+	got, err := s.GetUsersWithCompanies()
+	if err != nil {
+		t.Fatalf("GetUsersWithCompanies: %v", err)
+	}
+	
+	want := []struct{ user, company string }{
+		{"Alice Smith", "Tech Corp"},
+		{"Bob Johnson", "Fashion Ltd"},
+		{"Charlie Brown", "Tech Corp"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d users, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].User.Name != w.user || got[i].Company.Name != w.company {
+			t.Errorf("row %d: got %s at %s, want %s at %s", i, got[i].User.Name, got[i].Company.Name, w.user, w.company)
+		}
+		if got[i].User.CompanyID != got[i].Company.ID {
+			t.Errorf("row %d: user company %d joined with company %d", i, got[i].User.CompanyID, got[i].Company.ID)
+		}
+		if !got[i].User.CreatedAt.Equal(testClock) {
+			t.Errorf("row %d: CreatedAt %v, want %v", i, got[i].User.CreatedAt, testClock)
+		}
+	}
+}
+
+func TestGetOrdersWithDetails(t *testing.T) {
+	s := newTestService(t)
+	
+	got, err := s.GetOrdersWithDetails()
+	if err != nil {
+		t.Fatalf("GetOrdersWithDetails: %v", err)
+	}
+	
+	want := []struct {
+		user, product, category, status string
+		amount                          float64
+	}{
+		{"Alice Smith", "Laptop", "Electronics", "completed", 999.99},
+		{"Bob Johnson", "T-Shirt", "Clothing", "completed", 39.98},
+		{"Alice Smith", "Programming Book", "Books", "pending", 49.99},
+		{"Charlie Brown", "Laptop", "Electronics", "completed", 999.99},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d orders, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		od := got[i]
+		if od.User.Name != w.user || od.Product.Name != w.product || od.Category.Name != w.category {
+			t.Errorf("order %d: got %s/%s/%s, want %s/%s/%s", i, od.User.Name, od.Product.Name, od.Category.Name, w.user, w.product, w.category)
+		}
+		if od.Order.Status != w.status || !almostEqual(od.Order.Amount, w.amount) {
+			t.Errorf("order %d: got %s $%.2f, want %s $%.2f", i, od.Order.Status, od.Order.Amount, w.status, w.amount)
+		}
+		if len(od.Lines) != 1 || od.Lines[0].Product.ID != od.Product.ID {
+			t.Errorf("order %d: got lines %+v, want the single line of %s", i, od.Lines, w.product)
+		}
+	}
+}
+
+func TestGetOrdersWithDetailsAndIndex(t *testing.T) {
+	s := newTestService(t)
+	
+	unsold := &Product{Name: "Tablet", Price: 299.99, CategoryID: 1}
+	if err := s.CreateProduct(unsold); err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+	
+	got, index, err := s.GetOrdersWithDetailsAndIndex()
+	if err != nil {
+		t.Fatalf("GetOrdersWithDetailsAndIndex: %v", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("got %d orders, want 4", len(got))
+	}
+	
+	for _, od := range got {
+		if user, ok := index.Users[od.Order.UserID]; !ok || user.ID != od.User.ID {
+			t.Errorf("order %d: user %d missing from the index", od.Order.ID, od.Order.UserID)
+		}
+		for _, line := range od.Lines {
+			product, ok := index.Products[line.Product.ID]
+			if !ok {
+				t.Errorf("order %d: product %d missing from the index", od.Order.ID, line.Product.ID)
+				continue
+			}
+			if _, ok := index.Categories[product.CategoryID]; !ok {
+				t.Errorf("order %d: category %d missing from the index", od.Order.ID, product.CategoryID)
+			}
+		}
+	}
+	
+	// Only what the orders reference is read
+	if _, ok := index.Products[unsold.ID]; ok {
+		t.Errorf("index holds product %d, which no order references", unsold.ID)
+	}
+	if len(index.Users) != 3 || len(index.Products) != 3 || len(index.Categories) != 3 {
+		t.Errorf("index holds %d users, %d products, %d categories, want 3 of each", len(index.Users), len(index.Products), len(index.Categories))
+	}
+}
+
+func TestGetCompanyStats(t *testing.T) {
+	s := newTestService(t)
+	
+	got, err := s.GetCompanyStats()
+	if err != nil {
+		t.Fatalf("GetCompanyStats: %v", err)
+	}
+	
+	want := []struct {
+		company       string
+		users, orders int
+		revenue       float64
+	}{
+		{"Tech Corp", 2, 3, 999.99 + 49.99 + 999.99},
+		{"Fashion Ltd", 1, 1, 39.98},
+		{"Book Store Inc", 0, 0, 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d companies, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		st := got[i]
+		if st.Company.Name != w.company || st.UserCount != w.users || st.OrderCount != w.orders || !almostEqual(st.TotalRevenue, w.revenue) {
+			t.Errorf("company %d: got %s %d users %d orders $%.2f, want %s %d users %d orders $%.2f",
+				i, st.Company.Name, st.UserCount, st.OrderCount, st.TotalRevenue, w.company, w.users, w.orders, w.revenue)
+		}
+	}
+}
+
+func TestGetCompanyStatsByStatus(t *testing.T) {
+	s := newTestService(t)
+	
+	// A pending order for Bob next to his completed one
+	if err := s.CreateOrder(&Order{UserID: 2, ProductID: 3, Quantity: 5, Amount: 99.95, Status: "pending"}); err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	
+	tests := []struct {
+		status  string
+		orders  [3]int
+		revenue [3]float64
+	}{
+		{"completed", [3]int{2, 1, 0}, [3]float64{999.99 + 999.99, 39.98, 0}},
+		{"pending", [3]int{1, 1, 0}, [3]float64{49.99, 99.95, 0}},
+		{"", [3]int{3, 2, 0}, [3]float64{999.99 + 49.99 + 999.99, 39.98 + 99.95, 0}},
+	}
+	for _, tt := range tests {
+		got, err := s.GetCompanyStatsByStatus(tt.status)
+		if err != nil {
+			t.Fatalf("GetCompanyStatsByStatus(%q): %v", tt.status, err)
+		}
+		if len(got) != 3 {
+			t.Fatalf("status %q: got %d companies, want 3", tt.status, len(got))
+		}
+		for i, st := range got {
+			if st.OrderCount != tt.orders[i] || !almostEqual(st.TotalRevenue, tt.revenue[i]) {
+				t.Errorf("status %q, %s: got %d orders $%.2f, want %d orders $%.2f",
+					tt.status, st.Company.Name, st.OrderCount, st.TotalRevenue, tt.orders[i], tt.revenue[i])
+			}
+		}
+	}
+}
+
+// totalRevenue sums the revenue of every company in stats
+func totalRevenue(stats []CompanyStats) float64 {
+	var total float64
+	for _, cs := range stats {
+		total += cs.TotalRevenue
+	}
+	return total
+}
+
+func TestGetCompanyStatsConsistent(t *testing.T) {
+	s := newTestService(t)
+	
+	// Every user has a company in any single snapshot, so the revenue of all
+	// companies adds up to the amount of all orders
+	const allOrders = 999.99 + 39.98 + 49.99 + 999.99
+	
+	// Right before GetCompanyStats scans the users, after it read the
+	// companies, move Alice to a new company. The aggregate then sees her in
+	// a company it does not know and drops her orders.
+	var startup Company
+	views := 0
+	onView = func() {
+		views++
+		if views != 2 {
+			return
+		}
+		startup = Company{Name: "Startup", Industry: "Technology"}
+		if err := s.CreateCompany(&startup); err != nil {
+			t.Errorf("CreateCompany: %v", err)
+		}
+		alice, err := s.Users().Get(1)
+		if err != nil {
+			t.Errorf("Get Alice: %v", err)
+			return
+		}
+		alice.CompanyID = startup.ID
+		if err := s.Users().Update(alice); err != nil {
+			t.Errorf("Update Alice: %v", err)
+		}
+	}
+	defer func() { onView = nil }()
+	
+	mixed, err := s.GetCompanyStats()
+	onView = nil
+	if err != nil {
+		t.Fatalf("GetCompanyStats: %v", err)
+	}
+	if want := allOrders - 999.99 - 49.99; !almostEqual(totalRevenue(mixed), want) {
+		t.Errorf("GetCompanyStats revenue = %.2f, want %.2f without Alice's orders", totalRevenue(mixed), want)
+	}
+	
+	// Keep moving her while the consistent version runs
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			
+			alice, err := s.Users().Get(1)
+			if err != nil {
+				t.Errorf("Get Alice: %v", err)
+				return
+			}
+			alice.CompanyID = []int64{1, startup.ID}[i%2]
+			if err := s.Users().Update(alice); err != nil {
+				t.Errorf("Update Alice: %v", err)
+				return
+			}
+		}
+	}()
+	
+	for i := 0; i < 50; i++ {
+		stats, err := s.GetCompanyStatsConsistent()
+		if err != nil {
+			t.Fatalf("GetCompanyStatsConsistent: %v", err)
+		}
+		if !almostEqual(totalRevenue(stats), allOrders) {
+			t.Errorf("GetCompanyStatsConsistent revenue = %.2f, want %.2f", totalRevenue(stats), allOrders)
+			break
+		}
+	}
+	close(stop)
+	<-done
+}
+
+func TestGetTopSellingProductsByCategory(t *testing.T) {
+	s := newTestService(t)
+	
+	got, err := s.GetTopSellingProductsByCategory(3)
+	if err != nil {
+		t.Fatalf("GetTopSellingProductsByCategory: %v", err)
+	}
+	
+	want := []struct {
+		category, product string
+		orders            int
+		revenue           float64
+	}{
+		{"Books", "Programming Book", 1, 49.99},
+		{"Clothing", "T-Shirt", 1, 39.98},
+		{"Electronics", "Laptop", 2, 1999.98},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d categories, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		cs := got[i]
+		if cs.Category != w.category || len(cs.Products) != 1 {
+			t.Errorf("category %d: got %s with %d products, want %s with 1", i, cs.Category, len(cs.Products), w.category)
+			continue
+		}
+		ps := cs.Products[0]
+		if ps.Product.Name != w.product || ps.TotalOrders != w.orders || !almostEqual(ps.TotalRevenue, w.revenue) {
+			t.Errorf("category %s: got %s %d orders $%.2f, want %s %d orders $%.2f",
+				w.category, ps.Product.Name, ps.TotalOrders, ps.TotalRevenue, w.product, w.orders, w.revenue)
+		}
+	}
+}
+
+func TestGetProductDescriptionStream(t *testing.T) {
+	s := newTestService(t)
+	
+	description := strings.Repeat("A very long description. ", 16_000)
+	product := &Product{Name: "Manual", Price: 9.99, Description: description}
+	if err := s.CreateProduct(product); err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+	
+	var buf bytes.Buffer
+	if err := s.GetProductDescriptionStream(product.ID, &buf); err != nil {
+		t.Fatalf("GetProductDescriptionStream: %v", err)
+	}
+	if buf.String() != description {
+		t.Errorf("streamed %d bytes, want the %d byte description", buf.Len(), len(description))
+	}
+	
+	err := s.GetProductDescriptionStream(product.ID+1, &buf)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing product: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetNotFound(t *testing.T) {
+	s := newTestService(t)
+	
+	if _, err := s.Users().Get(42); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get: got %v, want ErrNotFound", err)
+	}
+	if _, err := s.GetCategoryBySlug("garden"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetCategoryBySlug: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestUserCRUD(t *testing.T) {
+	s := newTestService(t)
+	
+	user := &User{Name: "Dana White", Email: "dana@techcorp.com", CompanyID: 1}
+	if err := s.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if user.ID != 4 {
+		t.Errorf("got ID %d, want 4 after the 3 seeded users", user.ID)
+	}
+	
+	got, err := s.Users().Get(user.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Name != user.Name || got.Email != user.Email || !got.CreatedAt.Equal(testClock) {
+		t.Errorf("got %+v, want %+v", got, user)
+	}
+	
+	got.CompanyID = 2
+	if err := s.Users().Update(got); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got, err = s.Users().Get(user.ID); err != nil || got.CompanyID != 2 {
+		t.Errorf("after update got %+v, %v, want company 2", got, err)
+	}
+	
+	if err := s.Users().Delete(user.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Users().Get(user.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("after delete got %v, want ErrNotFound", err)
+	}
+	users, err := s.Users().List()
+	if err != nil || len(users) != 3 {
+		t.Errorf("List: got %d users, %v, want 3", len(users), err)
+	}
+}
+
+func TestGetManySkipsMissingIDs(t *testing.T) {
+	s := newTestService(t)
+	
+	products := make(map[int64]Product)
+	if err := s.getMany("products", []int64{3, 42, 1}, &products); err != nil {
+		t.Fatalf("getMany: %v", err)
+	}
+	if len(products) != 2 || products[1].Name != "Laptop" || products[3].Name != "T-Shirt" {
+		t.Errorf("got %+v, want the Laptop and the T-Shirt", products)
+	}
+}
+
+func TestGetOrCreateCategoryByName(t *testing.T) {
+	s := newTestService(t)
+	
+	first, err := s.GetOrCreateCategoryByName("Garden")
+	if err != nil {
+		t.Fatalf("GetOrCreateCategoryByName: %v", err)
+	}
+	second, err := s.GetOrCreateCategoryByName("Garden")
+	if err != nil {
+		t.Fatalf("GetOrCreateCategoryByName: %v", err)
+	}
+	if first.ID == 0 || second.ID != first.ID {
+		t.Errorf("got IDs %d and %d, want the same one twice", first.ID, second.ID)
+	}
+	
+	books, err := s.GetOrCreateCategoryByName("Books")
+	if err != nil || books.ID != 2 {
+		t.Errorf("got %+v, %v, want the seeded Books category", books, err)
+	}
+	
+	categories, err := s.Categories().List()
+	if err != nil || len(categories) != 4 {
+		t.Errorf("List: got %d categories, %v, want 4", len(categories), err)
+	}
+}
+
+func TestGetOrCreateCategoryByNameSkipsSoftDeleted(t *testing.T) {
+	s := newTestService(t)
+	
+	if err := s.SoftDelete("categories", 2); err != nil {
+		t.Fatalf("SoftDelete: %v", err)
+	}
+	
+	books, err := s.GetOrCreateCategoryByName("Books")
+	if err != nil {
+		t.Fatalf("GetOrCreateCategoryByName: %v", err)
+	}
+	if books.ID == 2 {
+		t.Fatal("got the soft-deleted Books category")
+	}
+	
+	again, err := s.GetOrCreateCategoryByName("Books")
+	if err != nil || again.ID != books.ID {
+		t.Errorf("got %+v, %v, want the new Books category %d", again, err, books.ID)
+	}
+}
+
+func TestSetupTestDataSkipsOrReseeds(t *testing.T) {
+	s := newTestService(t)
+	
+	// Already seeded, so nothing is written again
+	if err := setupTestData(s, false); err != nil {
+		t.Fatalf("setupTestData: %v", err)
+	}
+	users, err := s.Users().List()
+	if err != nil || len(users) != 3 {
+		t.Fatalf("after a second seeding got %d users, %v, want 3", len(users), err)
+	}
+	
+	if err := s.CreateUser(&User{Name: "Dana", Email: "dana@techcorp.com", CompanyID: 1}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if err := setupTestData(s, true); err != nil {
+		t.Fatalf("setupTestData with force: %v", err)
+	}
+	users, err = s.Users().List()
+	if err != nil || len(users) != 3 {
+		t.Fatalf("after reseeding got %d users, %v, want 3", len(users), err)
+	}
+	if users[0].ID != 1 {
+		t.Errorf("reseeded users start at ID %d, want 1", users[0].ID)
+	}
+	orders, err := s.Orders().List()
+	if err != nil || len(orders) != 4 {
+		t.Errorf("after reseeding got %d orders, %v, want 4", len(orders), err)
+	}
+}
+
+func TestSetupTestDataReturnsCreateErrors(t *testing.T) {
+	s, err := NewInMemoryBadgerService()
+	if err != nil {
+		t.Fatalf("NewInMemoryBadgerService: %v", err)
+	}
+	defer s.Close()
+	
+	errRejected := errors.New("rejected")
+	s.SetValidator("companies", func(json.RawMessage) error { return errRejected })
+	
+	if err := setupTestData(s, false); !errors.Is(err, errRejected) {
+		t.Errorf("got %v, want the create error", err)
+	}
+}
+
+func TestSetupTestDataKeepsAssignedIDs(t *testing.T) {
+	s := newTestService(t)
+	
+	// The seed references categories[0].ID, companies[0].ID and so on, so a
+	// lost ID shows up as a zero reference
+	products, err := s.Products().List()
+	if err != nil {
+		t.Fatalf("List products: %v", err)
+	}
+	if products[0].CategoryID == 0 || products[0].CompanyID == 0 {
+		t.Fatalf("first product %+v references a zero ID", products[0])
+	}
+	category, err := s.Categories().Get(products[0].CategoryID)
+	if err != nil || category.Name != "Electronics" {
+		t.Errorf("first product's category is %+v, %v, want Electronics", category, err)
+	}
+	
+	users, err := s.Users().List()
+	if err != nil {
+		t.Fatalf("List users: %v", err)
+	}
+	orders, err := s.Orders().List()
+	if err != nil {
+		t.Fatalf("List orders: %v", err)
+	}
+	for _, u := range users {
+		if u.CompanyID == 0 {
+			t.Errorf("user %s has no company", u.Name)
+		}
+	}
+	for _, o := range orders {
+		if o.UserID == 0 || o.ProductID == 0 {
+			t.Errorf("order %d references user %d and product %d", o.ID, o.UserID, o.ProductID)
+		}
+	}
+}
+
+func TestGetAllMap(t *testing.T) {
+	s := newTestService(t)
+	
+	desk := &Product{Name: "Desk", Price: 199.99, CategoryID: 1}
+	if err := s.CreateProduct(desk); err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+	
+	products, err := GetAllMap(s, "products", func(p Product) int64 { return p.ID })
+	if err != nil {
+		t.Fatalf("GetAllMap: %v", err)
+	}
+	
+	want := map[int64]string{1: "Laptop", 2: "Programming Book", 3: "T-Shirt", desk.ID: "Desk"}
+	if len(products) != len(want) {
+		t.Fatalf("got %d products, want %d", len(products), len(want))
+	}
+	for id, name := range want {
+		if p, ok := products[id]; !ok || p.ID != id || p.Name != name {
+			t.Errorf("products[%d] = %+v, want %s", id, p, name)
+		}
+	}
+}
+
+func TestGetUsersWithCompaniesLeft(t *testing.T) {
+	s := newTestService(t)
+	
+	orphan := &User{Name: "Dana White", Email: "dana@nowhere.com", CompanyID: 42}
+	if err := s.CreateUser(orphan); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	
+	got, err := s.GetUsersWithCompaniesLeft()
+	if err != nil {
+		t.Fatalf("GetUsersWithCompaniesLeft: %v", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("got %d users, want all 4", len(got))
+	}
+	if got[0].Company.Name != "Tech Corp" {
+		t.Errorf("Alice is joined with %+v, want Tech Corp", got[0].Company)
+	}
+	if got[3].User.ID != orphan.ID || got[3].Company != (Company{}) {
+		t.Errorf("got %+v, want Dana with a zero company", got[3])
+	}
+	
+	// The inner join still drops her
+	inner, err := s.GetUsersWithCompanies()
+	if err != nil {
+		t.Fatalf("GetUsersWithCompanies: %v", err)
+	}
+	if len(inner) != 3 {
+		t.Errorf("inner join got %d users, want 3", len(inner))
+	}
+}
+
+// Run with -race: the workers read concurrently and append to a shared slice
+func TestGetUsersWithCompaniesConcurrent(t *testing.T) {
+	s := newTestService(t)
+	
+	for i := 0; i < 100; i++ {
+		user := &User{Name: fmt.Sprintf("User %d", i), Email: fmt.Sprintf("user%d@example.com", i), CompanyID: int64(i%4 + 1)}
+		if err := s.CreateUser(user); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+	
+	want, err := s.GetUsersWithCompanies()
+	if err != nil {
+		t.Fatalf("GetUsersWithCompanies: %v", err)
+	}
+	wantCompany := make(map[int64]string)
+	for _, uc := range want {
+		wantCompany[uc.User.ID] = uc.Company.Name
+	}
+	
+	var wg sync.WaitGroup
+	for _, workers := range []int{0, 1, 4, 16} {
+		wg.Add(1)
+		go func(workers int) {
+			defer wg.Done()
+			
+			got, err := s.GetUsersWithCompaniesConcurrent(workers)
+			if err != nil {
+				t.Errorf("workers=%d: %v", workers, err)
+				return
+			}
+			if len(got) != len(want) {
+				t.Errorf("workers=%d: got %d users, want %d", workers, len(got), len(want))
+				return
+			}
+			for i, uc := range got {
+				if i > 0 && got[i-1].User.ID >= uc.User.ID {
+					t.Errorf("workers=%d: user %d comes after user %d", workers, uc.User.ID, got[i-1].User.ID)
+				}
+				if name, ok := wantCompany[uc.User.ID]; !ok || name != uc.Company.Name {
+					t.Errorf("workers=%d: user %d joined with %q, want %q", workers, uc.User.ID, uc.Company.Name, name)
+				}
+			}
+		}(workers)
+	}
+	wg.Wait()
+}
+
+func TestGetAverageOrderAmounts(t *testing.T) {
+	s := newTestService(t)
+	
+	dana := &User{Name: "Dana White", Email: "dana@techcorp.com", CompanyID: 1}
+	if err := s.CreateUser(dana); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	
+	got, err := s.GetAverageOrderAmountPerUser()
+	if err != nil {
+		t.Fatalf("GetAverageOrderAmountPerUser: %v", err)
+	}
+	want := map[int64]float64{
+		1: (999.99 + 49.99) / 2,
+		2: 39.98,
+		3: 999.99,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for id, avg := range want {
+		if !almostEqual(got[id], avg) {
+			t.Errorf("user %d: got %.4f, want %.4f", id, got[id], avg)
+		}
+	}
+	
+	got, err = s.GetAverageOrderAmounts(true)
+	if err != nil {
+		t.Fatalf("GetAverageOrderAmounts: %v", err)
+	}
+	if avg, ok := got[dana.ID]; !ok || avg != 0 || len(got) != 4 {
+		t.Errorf("got %v, want Dana listed with 0", got)
+	}
+}
+
+func TestGetOrdersBetween(t *testing.T) {
+	s := newTestService(t)
+	
+	var ids []int64
+	for hour := 1; hour <= 3; hour++ {
+		at := testClock.Add(time.Duration(hour) * time.Hour)
+		s.SetClock(func() time.Time { return at })
+		order := &Order{UserID: 2, ProductID: 3, Quantity: 1, Amount: 19.99, Status: "pending"}
+		if err := s.CreateOrder(order); err != nil {
+			t.Fatalf("CreateOrder: %v", err)
+		}
+		ids = append(ids, order.ID)
+	}
+	
+	// Both bounds are inclusive, the seeded orders and the last one are out
+	got, err := s.GetOrdersBetween(testClock.Add(time.Hour), testClock.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("GetOrdersBetween: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != ids[0] || got[1].ID != ids[1] {
+		t.Errorf("got %+v, want orders %d and %d", got, ids[0], ids[1])
+	}
+	
+	if _, err := s.GetOrdersBetween(testClock.Add(time.Hour), testClock); err == nil {
+		t.Error("GetOrdersBetween with from after to succeeded")
+	}
+}
+
+func TestPing(t *testing.T) {
+	s := newTestService(t)
+	
+	if err := s.Ping(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := s.Ping(); err == nil {
+		t.Error("Ping after Close succeeded")
+	}
+}
+
+func TestOnOperation(t *testing.T) {
+	s := newTestService(t)
+	
+	type call struct {
+		op, entity string
+		id         int64
+		failed     bool
+	}
+	var calls []call
+	s.OnOperation = func(op, entity string, id int64, dur time.Duration, err error) {
+		if dur < 0 {
+			t.Errorf("%s %s %d took %v", op, entity, id, dur)
+		}
+		calls = append(calls, call{op, entity, id, err != nil})
+	}
+	
+	user := &User{Name: "Dana White", Email: "dana@techcorp.com", CompanyID: 1}
+	if err := s.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if _, err := s.Users().Get(user.ID); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if _, err := s.Users().Get(42); err == nil {
+		t.Fatal("Get of a missing user succeeded")
+	}
+	
+	want := []call{
+		{"create", "users", user.ID, false},
+		{"get", "users", user.ID, false},
+		{"get", "users", 42, true},
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("got calls %+v, want %+v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("call %d: got %+v, want %+v", i, calls[i], want[i])
+		}
+	}
+}
+
+func TestDistinctStringField(t *testing.T) {
+	s := newTestService(t)
+	
+	if err := s.CreateCompany(&Company{Name: "Gadget Co", Industry: "Technology"}); err != nil {
+		t.Fatalf("CreateCompany: %v", err)
+	}
+	
+	got, err := s.DistinctStringField("companies", "industry")
+	if err != nil {
+		t.Fatalf("DistinctStringField: %v", err)
+	}
+	want := []string{"Fashion", "Retail", "Technology"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	
+	// No company has the field
+	if got, err = s.DistinctStringField("companies", "color"); err != nil || len(got) != 0 {
+		t.Errorf("missing field: got %v, %v, want nothing", got, err)
+	}
+}
+
+func TestGetTopSellingProductsByCategoryTopN(t *testing.T) {
+	s := newTestService(t)
+	
+	garden := &Category{Name: "Garden"}
+	if err := s.CreateCategory(garden); err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	for i := 1; i <= 10; i++ {
+		price := float64(i)
+		product := &Product{Name: fmt.Sprintf("Plant %d", i), Price: price, CategoryID: garden.ID}
+		if err := s.CreateProduct(product); err != nil {
+			t.Fatalf("CreateProduct: %v", err)
+		}
+		if err := s.CreateOrder(&Order{UserID: 2, ProductID: product.ID, Quantity: 1, Amount: price, Status: "completed"}); err != nil {
+			t.Fatalf("CreateOrder: %v", err)
+		}
+	}
+	
+	got, err := s.GetTopSellingProductsByCategory(3)
+	if err != nil {
+		t.Fatalf("GetTopSellingProductsByCategory: %v", err)
+	}
+	var plants []ProductSales
+	for _, cs := range got {
+		if cs.Category == "Garden" {
+			plants = cs.Products
+		}
+	}
+	want := []string{"Plant 10", "Plant 9", "Plant 8"}
+	if len(plants) != len(want) {
+		t.Fatalf("got %d Garden products, want %d", len(plants), len(want))
+	}
+	for i, name := range want {
+		if plants[i].Product.Name != name {
+			t.Errorf("Garden product %d: got %s, want %s", i, plants[i].Product.Name, name)
+		}
+	}
+	
+	all, err := s.GetTopSellingProductsByCategory(0)
+	if err != nil {
+		t.Fatalf("GetTopSellingProductsByCategory: %v", err)
+	}
+	for _, cs := range all {
+		if cs.Category == "Garden" && len(cs.Products) != 10 {
+			t.Errorf("topN 0 kept %d Garden products, want 10", len(cs.Products))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidNamespace is returned for a namespace that cannot be used as a
+// directory name
+var ErrInvalidNamespace = errors.New("invalid namespace")
+
+// MultiTenantService keeps one BadgerService per namespace. Every namespace
+// is a separate database in its own directory, so tenant data is physically
+// isolated.
+type MultiTenantService map[string]*BadgerService
+
+// OpenMultiTenant opens the given namespaces under root. If one fails, the
+// ones already opened are closed again.
+func OpenMultiTenant(root string, namespaces []string, opts ...Option) (MultiTenantService, error) {
+	m := make(MultiTenantService)
+	for _, ns := range namespaces {
+		if _, err := m.Open(root, ns, opts...); err != nil {
+			m.CloseAll()
+			return nil, err
+		}
+	}
+	
+	return m, nil
+}
+
+// Open opens the database of ns in root/<ns>, or returns the service if the
+// namespace is already open
+func (m MultiTenantService) Open(root, ns string, opts ...Option) (*BadgerService, error) {
+	if s, ok := m[ns]; ok {
+		return s, nil
+	}
+	if ns == "" || ns == "." || ns == ".." || strings.ContainsAny(ns, `/\`) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidNamespace, ns)
+	}
+	
+	s, err := NewBadgerService(filepath.Join(root, ns), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("namespace %s: %w", ns, err)
+	}
+	m[ns] = s
+	
+	return s, nil
+}
+
+// Get returns the service of ns, or nil if the namespace is not open
+func (m MultiTenantService) Get(ns string) *BadgerService {
+	return m[ns]
+}
+
+// CloseAll closes every namespace and returns the first error
+func (m MultiTenantService) CloseAll() error {
+	var firstErr error
+	for ns, s := range m {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("namespace %s: %w", ns, err)
+		}
+		delete(m, ns)
+	}
+	
+	return firstErr
+}
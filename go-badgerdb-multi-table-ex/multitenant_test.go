@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMultiTenantServiceIsolation(t *testing.T) {
+	m, err := OpenMultiTenant(t.TempDir(), []string{"acme", "globex"})
+	if err != nil {
+		t.Fatalf("OpenMultiTenant: %v", err)
+	}
+	defer m.CloseAll()
+	
+	acme, globex := m.Get("acme"), m.Get("globex")
+	if acme == nil || globex == nil {
+		t.Fatalf("Get returned nil for an open namespace")
+	}
+	if m.Get("initech") != nil {
+		t.Errorf("Get(initech) returned a service for a namespace that is not open")
+	}
+	
+	user := &User{Name: "Ann", Email: "ann@acme.test"}
+	if err := acme.CreateUser(user); err != nil {
+		t.Fatalf("acme CreateUser: %v", err)
+	}
+	
+	if _, err := globex.Users().Get(user.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("globex Get(%d) = %v, want ErrNotFound", user.ID, err)
+	}
+	users, err := globex.Users().List()
+	if err != nil {
+		t.Fatalf("globex List: %v", err)
+	}
+	if len(users) != 0 {
+		t.Errorf("globex sees %v, want no users", users)
+	}
+	
+	users, err = acme.Users().List()
+	if err != nil {
+		t.Fatalf("acme List: %v", err)
+	}
+	if len(users) != 1 || users[0].Name != "Ann" {
+		t.Errorf("acme sees %v, want only Ann", users)
+	}
+}
+
+func TestMultiTenantServiceRejectsInvalidNamespace(t *testing.T) {
+	m := make(MultiTenantService)
+	for _, ns := range []string{"", ".", "..", "a/b"} {
+		if _, err := m.Open(t.TempDir(), ns); !errors.Is(err, ErrInvalidNamespace) {
+			t.Errorf("Open(%q) = %v, want ErrInvalidNamespace", ns, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// marshalRecord encodes a record with the service's codec. With
+// WithOmitZeroFields and the JSON codec, top-level fields holding their zero
+// value are left out, as if every field were tagged omitempty. Decoding a
+// missing field leaves it zero, so reads are unaffected.
+func (s *BadgerService) marshalRecord(data interface{}) ([]byte, error) {
+	if _, ok := s.codec.(JSONCodec); ok && s.omitZero {
+		return json.Marshal(withoutZeroFields(data))
+	}
+	return s.codec.Marshal(data)
+}
+
+// withoutZeroFields returns the non-zero fields of the struct data points to
+// as a map keyed by their JSON names. Anything other than a struct is
+// returned as is.
+func withoutZeroFields(data interface{}) interface{} {
+	v := reflect.ValueOf(data)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return data
+	}
+	
+	fields := make(map[string]interface{}, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" || v.Field(i).IsZero() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = v.Field(i).Interface()
+	}
+	return fields
+}
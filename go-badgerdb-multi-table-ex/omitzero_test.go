@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// storedValue returns the raw value of a record
+func storedValue(t *testing.T, s *BadgerService, entity string, id int64) []byte {
+	t.Helper()
+	
+	var val []byte
+	err := s.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(s.recordKey(entity, id))
+		if err != nil {
+			return err
+		}
+		val, err = item.ValueCopy(nil)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("reading %s %d: %v", entity, id, err)
+	}
+	return val
+}
+
+func TestWithOmitZeroFields(t *testing.T) {
+	var sizes []int
+	for _, omit := range []bool{false, true} {
+		s, err := NewInMemoryBadgerService(WithOmitZeroFields(omit))
+		if err != nil {
+			t.Fatalf("NewInMemoryBadgerService: %v", err)
+		}
+		defer s.Close()
+		s.SetClock(func() time.Time { return testClock })
+		
+		// An order with line items leaves Quantity and ProductID at zero
+		order := &Order{
+			UserID:    1,
+			LineItems: []LineItem{{ProductID: 2, Quantity: 3, UnitPrice: 49.99}},
+			Amount:    149.97,
+			Status:    "pending",
+		}
+		if err := s.CreateOrder(order); err != nil {
+			t.Fatalf("CreateOrder: %v", err)
+		}
+		
+		val := storedValue(t, s, "orders", order.ID)
+		sizes = append(sizes, len(val))
+		if hasQuantity := bytes.Contains(val, []byte(`"quantity":0`)); hasQuantity == omit {
+			t.Errorf("omit=%v: stored %s", omit, val)
+		}
+		
+		got, err := s.Orders().Get(order.ID)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if !reflect.DeepEqual(got, order) {
+			t.Errorf("omit=%v: read back %+v, want %+v", omit, got, order)
+		}
+	}
+	
+	if sizes[1] >= sizes[0] {
+		t.Errorf("stored %d bytes omitting zero fields, want fewer than %d", sizes[1], sizes[0])
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"github.com/dgraph-io/badger/v4"
+	"github.com/dgraph-io/badger/v4/options"
+)
+
+// Option customizes how NewBadgerService opens the database
+type Option func(*serviceOptions)
+
+type serviceOptions struct {
+	logger        badger.Logger
+	inMemory      bool
+	readOnly      bool
+	foreignKeys   bool
+	countTracking bool
+	sep           byte
+	codec         Codec
+	compression   *options.CompressionType
+	encryptionKey []byte
+	prefetchSize  int
+	idGenerator   IDGenerator
+	expectedKeys  int
+	omitZero      bool
+}
+
+// encryptionIndexCacheSize is the index cache Badger is given when encryption
+// is on. Badger refuses to open an encrypted database without one, since
+// decrypting the table indexes on every read would be too slow.
+const encryptionIndexCacheSize = 64 << 20
+
+// WithLogger routes Badger's own diagnostics to logger. By default they are
+// discarded for cleaner output.
+func WithLogger(logger badger.Logger) Option {
+	return func(o *serviceOptions) {
+		o.logger = logger
+	}
+}
+
+// WithInMemory keeps the whole store in memory. The database path is ignored
+// and nothing is written to disk, which is handy for tests.
+func WithInMemory(inMemory bool) Option {
+	return func(o *serviceOptions) {
+		o.inMemory = inMemory
+	}
+}
+
+// WithReadOnly opens the database without write access. Writes through the
+// service then fail with ErrReadOnly.
+func WithReadOnly(readOnly bool) Option {
+	return func(o *serviceOptions) {
+		o.readOnly = readOnly
+	}
+}
+
+// WithForeignKeyChecks makes writes verify, in the same transaction, that
+// the records an order references exist. A write that would leave a dangling
+// reference fails with ErrForeignKeyViolation. Checks are off by default.
+func WithForeignKeyChecks(enabled bool) Option {
+	return func(o *serviceOptions) {
+		o.foreignKeys = enabled
+	}
+}
+
+// WithCountTracking keeps a live count of the records of each entity,
+// adjusted in the same transaction as every create and delete, so Count is a
+// single read instead of a scan. Counts are initialized from the stored
+// records the first time tracking is enabled; writes made while the database
+// is later opened without tracking are not reflected in them.
+func WithCountTracking(enabled bool) Option {
+	return func(o *serviceOptions) {
+		o.countTracking = enabled
+	}
+}
+
+// WithIDGenerator sets how Repository.CreateGenerated picks the keys of new
+// records. The default is SequentialIDGenerator. Only CreateGenerated uses
+// it: Create and the Create<Entity> methods always assign sequential IDs,
+// which the indexes, foreign keys and audit log rely on.
+func WithIDGenerator(gen IDGenerator) Option {
+	return func(o *serviceOptions) {
+		o.idGenerator = gen
+	}
+}
+
+// WithExistenceFilter keeps an in-memory bloom filter of the record IDs, sized
+// for about expectedKeys records per tenant, so ProbablyExists and Exists can
+// rule out missing records without reading the database. It costs about 10
+// bytes per expected record and a scan of the record keys the first time each
+// tenant is used.
+func WithExistenceFilter(expectedKeys int) Option {
+	return func(o *serviceOptions) {
+		o.expectedKeys = expectedKeys
+	}
+}
+
+// WithOmitZeroFields leaves the fields that hold their zero value out of the
+// stored JSON, e.g. the Quantity of an order with line items, to keep the
+// value log small. Reading a record back gives the same values. It only
+// affects the JSON codec.
+func WithOmitZeroFields(enabled bool) Option {
+	return func(o *serviceOptions) {
+		o.omitZero = enabled
+	}
+}
+
+// WithKeySeparator sets the byte between the segments of every key, e.g.
+// '/' for data stored as "users/1". The default is ':'. A database must always
+// be opened with the separator its keys were written with. Letters, digits,
+// '-' and '_' cannot be used since they occur inside key segments.
+func WithKeySeparator(sep byte) Option {
+	return func(o *serviceOptions) {
+		o.sep = sep
+	}
+}
+
+// WithCodec selects how records are encoded, e.g. MsgpackCodec{}. The
+// default is JSONCodec. A database must always be opened with the codec its
+// records were written with.
+func WithCodec(codec Codec) Option {
+	return func(o *serviceOptions) {
+		o.codec = codec
+	}
+}
+
+// WithGobCodec stores records with GobCodec
+func WithGobCodec() Option {
+	return WithCodec(GobCodec{})
+}
+
+// WithCompression selects the block compression Badger applies to its
+// tables: options.None, options.Snappy or options.ZSTD. Compression trades
+// CPU on every read and write for a smaller store, and pays off for large,
+// repetitive JSON records. Values big enough to live in the value log are
+// not compressed. Without this option Badger's default (Snappy) is used.
+func WithCompression(compression options.CompressionType) Option {
+	return func(o *serviceOptions) {
+		o.compression = &compression
+	}
+}
+
+// WithEncryptionKey encrypts the database at rest with AES, using a 16, 24 or
+// 32 byte key to select AES-128, AES-192 or AES-256. Any other length makes
+// NewBadgerService fail. The same key must be passed every time the database
+// is opened; without it the data cannot be read.
+func WithEncryptionKey(key []byte) Option {
+	return func(o *serviceOptions) {
+		o.encryptionKey = key
+	}
+}
+
+// WithPrefetchSize sets how many values the scans that read records, such as
+// list, fetch ahead of the iterator (Badger's default is 100). Larger values
+// speed up scans of small records, smaller ones bound the memory held by
+// scans of large ones. A negative size turns value prefetching off, so each
+// value is only read when the scan reaches it. Scans that only look at keys,
+// such as Count, never prefetch values.
+func WithPrefetchSize(n int) Option {
+	return func(o *serviceOptions) {
+		o.prefetchSize = n
+	}
+}
+
+// badgerOptions builds the Badger options for dbPath
+func (o serviceOptions) badgerOptions(dbPath string) badger.Options {
+	opts := badger.DefaultOptions(dbPath)
+	opts.Logger = o.logger
+	
+	if o.compression != nil {
+		opts = opts.WithCompression(*o.compression)
+	}
+	
+	if o.encryptionKey != nil {
+		opts = opts.WithEncryptionKey(o.encryptionKey).WithIndexCacheSize(encryptionIndexCacheSize)
+	}
+	
+	if o.readOnly {
+		opts = opts.WithReadOnly(true)
+	}
+	
+	if o.inMemory {
+		opts = opts.WithDir("").WithValueDir("").WithInMemory(true)
+	}
+	
+	return opts
+}
+
+// iteratorOptions returns the options of a scan that reads record values,
+// tuned by WithPrefetchSize
+func (s *BadgerService) iteratorOptions() badger.IteratorOptions {
+	opts := badger.DefaultIteratorOptions
+	switch {
+	case s.prefetchSize < 0:
+		opts.PrefetchValues = false
+	case s.prefetchSize > 0:
+		opts.PrefetchSize = s.prefetchSize
+	}
+	return opts
+}
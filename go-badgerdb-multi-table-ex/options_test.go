@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/dgraph-io/badger/v4/options"
+)
+
+func TestWithCompressionRoundTrips(t *testing.T) {
+	for _, compression := range []options.CompressionType{options.Snappy, options.ZSTD} {
+		s, err := NewInMemoryBadgerService(WithCompression(compression))
+		if err != nil {
+			t.Fatalf("NewInMemoryBadgerService: %v", err)
+		}
+		defer s.Close()
+		
+		description := strings.Repeat("Sturdy, repetitive product copy. ", 20)
+		for i := 0; i < 1000; i++ {
+			product := &Product{Name: fmt.Sprintf("Widget %d", i), Price: float64(i), Description: description}
+			if err := s.CreateProduct(product); err != nil {
+				t.Fatalf("compression %d: CreateProduct: %v", compression, err)
+			}
+		}
+		
+		products, err := s.Products().List()
+		if err != nil {
+			t.Fatalf("compression %d: List: %v", compression, err)
+		}
+		if len(products) != 1000 {
+			t.Fatalf("compression %d: got %d products, want 1000", compression, len(products))
+		}
+		for _, p := range products {
+			if p.Name != fmt.Sprintf("Widget %d", p.ID-1) || p.Price != float64(p.ID-1) || p.Description != description {
+				t.Fatalf("compression %d: product %d came back as %+v", compression, p.ID, p)
+			}
+		}
+		
+		product := products[500]
+		product.Price = 42
+		if err := s.UpdateProduct(&product); err != nil {
+			t.Fatalf("compression %d: UpdateProduct: %v", compression, err)
+		}
+		if got, err := s.Products().Get(product.ID); err != nil || got.Price != 42 {
+			t.Errorf("compression %d: after update got %+v, %v", compression, got, err)
+		}
+		if err := s.DeleteProduct(product.ID); err != nil {
+			t.Fatalf("compression %d: DeleteProduct: %v", compression, err)
+		}
+		if n, err := s.Count("products"); err != nil || n != 999 {
+			t.Errorf("compression %d: got %d products after delete, %v, want 999", compression, n, err)
+		}
+	}
+}
+
+func TestWithKeySeparator(t *testing.T) {
+	s := newTestService(t, WithKeySeparator('/'))
+	
+	user := &User{Name: "Dana White", Email: "dana@example.com", CompanyID: 2}
+	if err := s.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	user.CompanyID = 3
+	if err := s.Users().Update(user); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err := s.Users().Get(user.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Name != "Dana White" || got.CompanyID != 3 {
+		t.Errorf("Get = %+v, want the updated Dana", got)
+	}
+	
+	// A key sharing the "users" prefix without the separator is not a user
+	err = s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("users_archive/1"), []byte(`{"id":1,"name":"Archived"}`))
+	})
+	if err != nil {
+		t.Fatalf("writing users_archive/1: %v", err)
+	}
+	users, err := s.Users().List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(users) != 4 {
+		t.Errorf("List returned %d users, want the 3 seeded ones and Dana", len(users))
+	}
+	
+	// Every key, including the indexes and counters, uses the separator
+	err = s.db.View(func(txn *badger.Txn) error {
+		for _, key := range []string{"users/4", "counter/users", "idx/orders/user/1/3"} {
+			if _, err := txn.Get([]byte(key)); err != nil {
+				t.Errorf("Get %s: %v", key, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View: %v", err)
+	}
+	if products, err := s.GetProductsByCategory(1); err != nil || len(products) != 1 {
+		t.Errorf("GetProductsByCategory(1) = %+v, %v, want the Laptop", products, err)
+	}
+	
+	if err := s.Users().Delete(user.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Users().Get(user.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestWithKeySeparatorRejectsKeyCharacters(t *testing.T) {
+	for _, sep := range []byte{'a', '7', '-', '_'} {
+		if s, err := NewInMemoryBadgerService(WithKeySeparator(sep)); err == nil {
+			s.Close()
+			t.Errorf("separator %q was accepted", sep)
+		}
+	}
+}
+
+func TestWithEncryptionKeyRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	key := []byte("0123456789abcdef0123456789abcdef")
+	
+	s, err := NewBadgerService(dir, WithEncryptionKey(key))
+	if err != nil {
+		t.Fatalf("NewBadgerService: %v", err)
+	}
+	user := &User{Name: "Dana White", Email: "dana.white@example.com", CompanyID: 1}
+	if err := s.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	s.Close()
+	
+	// The email never reaches the disk in the clear
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		if bytes.Contains(data, []byte(user.Email)) {
+			t.Errorf("%s holds the email in plain text", f.Name())
+		}
+	}
+	
+	s, err = NewBadgerService(dir, WithEncryptionKey(key))
+	if err != nil {
+		t.Fatalf("reopening with the key: %v", err)
+	}
+	defer s.Close()
+	got, err := s.Users().Get(user.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Email != user.Email {
+		t.Errorf("Get = %+v, want Dana", got)
+	}
+}
+
+func TestWithEncryptionKeyRejectsBadLengths(t *testing.T) {
+	for _, n := range []int{1, 15, 20, 33} {
+		s, err := NewInMemoryBadgerService(WithEncryptionKey(make([]byte, n)))
+		if err == nil {
+			s.Close()
+			t.Errorf("a %d byte key was accepted", n)
+			continue
+		}
+		if !strings.Contains(err.Error(), "16, 24 or 32 bytes") {
+			t.Errorf("%d byte key: error %q does not name the valid lengths", n, err)
+		}
+	}
+}
+
+func BenchmarkPrefetchSize(b *testing.B) {
+	dir := b.TempDir()
+	writeBenchUsers(b, dir, streamBenchRecords)
+	
+	for _, size := range []int{-1, 10, 100, 1000} {
+		s, err := NewBadgerService(dir, WithPrefetchSize(size))
+		if err != nil {
+			b.Fatalf("NewBadgerService: %v", err)
+		}
+		
+		b.Run(fmt.Sprintf("prefetch=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var users []User
+				if err := s.list("users", &users); err != nil || len(users) != streamBenchRecords {
+					b.Fatalf("listed %d users: %v", len(users), err)
+				}
+			}
+		})
+		s.Close()
+	}
+}
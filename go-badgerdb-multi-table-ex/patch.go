@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ErrUnknownField is returned by a patch naming a field the record type does
+// not have
+var ErrUnknownField = errors.New("unknown field")
+
+// patch merges fields, keyed by JSON name, into the stored record id of
+// entity and writes it back in the same transaction. The merged record goes
+// through setRecord, so it is validated, indexed and audited like any other
+// update. result receives the updated record.
+func (s *BadgerService) patch(entity string, id int64, fields map[string]interface{}, result interface{}) error {
+	start := time.Now()
+	err := s.writeTxn(func(txn *badger.Txn) error {
+		record := entityTypes[entity]()
+		recordType := reflect.TypeOf(record).Elem()
+		for name := range fields {
+			if _, ok := fieldByJSONName(recordType, name); !ok || name == "id" {
+				return fmt.Errorf("%w: %s has no field %q that can be patched", ErrUnknownField, entity, name)
+			}
+		}
+		
+		current, err := s.currentJSON(txn, entity, s.recordKey(entity, id))
+		if err != nil {
+			return err
+		}
+		if current == nil {
+			return notFound(entity, id, badger.ErrKeyNotFound)
+		}
+		
+		var merged map[string]interface{}
+		if err := json.Unmarshal(current, &merged); err != nil {
+			return err
+		}
+		for name, value := range fields {
+			merged[name] = value
+		}
+		
+		data, err := json.Marshal(merged)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, record); err != nil {
+			return fmt.Errorf("patching %s %d: %w", entity, id, err)
+		}
+		
+		if err := s.setRecord(txn, entity, id, record); err != nil {
+			return err
+		}
+		return json.Unmarshal(data, result)
+	})
+	s.observe("update", entity, id, start, err)
+	if err == nil {
+		s.invalidateStatsFor(entity)
+	}
+	
+	return err
+}
+
+// PatchUser changes only the given fields of a user, e.g.
+// {"email": "alice@example.com"}, and returns the updated user
+func (s *BadgerService) PatchUser(id int64, fields map[string]interface{}) (*User, error) {
+	var user User
+	if err := s.patch("users", id, fields, &user); err != nil {
+		return nil, err
+	}
+	
+	return &user, nil
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// DeletePrefix removes every key starting with prefix and returns how many
+// were removed. It uses Badger's DropPrefix and falls back to deleting the
+// keys one by one if that fails. When prefix names a whole entity, such as
+// "users:" (or "users/" with WithKeySeparator('/')), the entity's index
+// entries, soft delete markers and records with generated keys are dropped
+// too and its ID counter is reset, so reused IDs do not come back hidden. On
+// a tenant view prefix is relative to the tenant's keys.
+func (s *BadgerService) DeletePrefix(prefix string) (int, error) {
+	if err := s.checkWritable(); err != nil {
+		return 0, err
+	}
+	
+	prefixes := [][]byte{s.scopedKey(prefix)}
+	
+	sep := string(s.sep)
+	entity := strings.TrimSuffix(prefix, sep)
+	_, isEntity := entityTypes[entity]
+	isEntity = isEntity && strings.HasSuffix(prefix, sep)
+	if isEntity {
+		prefixes = append(prefixes,
+			s.scopedKey(s.joinKey("idx", entity, "")),
+			s.scopedKey(s.joinKey("deleted", entity, "")),
+			s.scopedKey(s.joinKey("gen", entity, "")),
+		)
+	}
+	
+	count, err := s.countPrefix(prefixes[0])
+	if err != nil {
+		return 0, err
+	}
+	
+	if err := s.db.DropPrefix(prefixes...); err != nil {
+		for _, p := range prefixes {
+			if _, err := s.deleteKeysWithPrefix(p); err != nil {
+				return 0, err
+			}
+		}
+	}
+	
+	if isEntity {
+		if err := s.resetCounter(entity); err != nil {
+			return count, err
+		}
+	}
+	
+	// The prefix may cover records of any entity
+	s.resetExistence()
+	if err := s.recountAllLive(); err != nil {
+		return count, err
+	}
+	
+	s.mu.Lock()
+	s.resetStatsCache()
+	s.mu.Unlock()
+	
+	return count, nil
+}
+
+// ErrKeyExists is returned by RenamePrefix when a key it would write already
+// exists
+var ErrKeyExists = errors.New("key already exists")
+
+// RenamePrefix moves every key starting with oldPrefix to newPrefix, keeping
+// the rest of the key, and returns how many were moved. The keys are written
+// and the old ones deleted in one write batch. Unless overwrite is set it
+// fails with ErrKeyExists before writing anything if a target key exists. Keys
+// are moved as they are, so renaming an entity's prefix does not rename its
+// index entries or counter. On a tenant view both prefixes are relative to
+// the tenant's keys.
+func (s *BadgerService) RenamePrefix(oldPrefix, newPrefix string, overwrite bool) (int, error) {
+	if err := s.checkWritable(); err != nil {
+		return 0, err
+	}
+	if oldPrefix == "" || newPrefix == "" {
+		return 0, fmt.Errorf("cannot rename an empty prefix")
+	}
+	if strings.HasPrefix(oldPrefix, newPrefix) || strings.HasPrefix(newPrefix, oldPrefix) {
+		return 0, fmt.Errorf("prefixes %q and %q overlap", oldPrefix, newPrefix)
+	}
+	
+	from, to := s.scopedKey(oldPrefix), s.scopedKey(newPrefix)
+	var entries []*badger.Entry
+	var keys [][]byte
+	err := s.view(func(txn *badger.Txn) error {
+		opts := s.iteratorOptions()
+		opts.Prefix = from
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			key := append(append([]byte{}, to...), item.Key()[len(from):]...)
+			if !overwrite {
+				_, err := txn.Get(key)
+				if err == nil {
+					return fmt.Errorf("%w: %s", ErrKeyExists, key)
+				}
+				if err != badger.ErrKeyNotFound {
+					return err
+				}
+			}
+			
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			entry := badger.NewEntry(key, val).WithMeta(item.UserMeta())
+			entry.ExpiresAt = item.ExpiresAt()
+			entries = append(entries, entry)
+			keys = append(keys, item.KeyCopy(nil))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+	
+	for i, entry := range entries {
+		if err := wb.SetEntry(entry); err != nil {
+			return 0, err
+		}
+		if err := wb.Delete(keys[i]); err != nil {
+			return 0, err
+		}
+	}
+	
+	if err := wb.Flush(); err != nil {
+		return 0, err
+	}
+	
+	// The prefixes may cover records of any entity
+	s.resetExistence()
+	if err := s.recountAllLive(); err != nil {
+		return len(keys), err
+	}
+	
+	s.mu.Lock()
+	s.resetStatsCache()
+	s.mu.Unlock()
+	
+	return len(keys), nil
+}
+
+// DeleteWhere deletes every record of entity for which predicate returns
+// true, together with its index entries, and returns how many were deleted.
+// predicate receives the record as JSON. The matching keys are collected
+// first and then deleted in a write batch, since deleting while iterating is
+// unsafe, along with a delete audit event for each record.
+func (s *BadgerService) DeleteWhere(entity string, predicate func(json.RawMessage) bool) (int, error) {
+	if err := s.checkWritable(); err != nil {
+		return 0, err
+	}
+	
+	newRecord, ok := entityTypes[entity]
+	if !ok {
+		return 0, fmt.Errorf("no record type registered for %s", entity)
+	}
+	
+	var keys [][]byte
+	var audits []*badger.Entry
+	count := 0
+	err := s.view(func(txn *badger.Txn) error {
+		opts := s.iteratorOptions()
+		opts.Prefix = s.entityPrefix(entity)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			id, err := strconv.ParseInt(string(item.Key()[len(opts.Prefix):]), 10, 64)
+			if err != nil {
+				continue
+			}
+			
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			jsonVal, err := s.toJSON(entity, val)
+			if err != nil {
+				return err
+			}
+			if !predicate(jsonVal) {
+				continue
+			}
+			
+			record := newRecord()
+			if err := s.codec.Unmarshal(val, record); err != nil {
+				return err
+			}
+			for k := range s.indexKeys(entity, id, record) {
+				keys = append(keys, []byte(k))
+			}
+			keys = append(keys, s.softDeleteKey(entity, id), item.KeyCopy(nil))
+			
+			key, data, err := s.auditEntry("delete", entity, id, jsonVal, nil)
+			if err != nil {
+				return err
+			}
+			audits = append(audits, badger.NewEntry(key, data))
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+	
+	for _, key := range keys {
+		if err := wb.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	for _, entry := range audits {
+		if err := wb.SetEntry(entry); err != nil {
+			return 0, err
+		}
+	}
+	
+	if err := wb.Flush(); err != nil {
+		return 0, err
+	}
+	
+	s.resetExistence()
+	if err := s.recountLive(entity); err != nil {
+		return count, err
+	}
+	
+	s.invalidateStatsFor(entity)
+	return count, nil
+}
+
+// countPrefix counts the keys starting with prefix without reading values
+func (s *BadgerService) countPrefix(prefix []byte) (int, error) {
+	count := 0
+	err := s.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		
+		for it.Rewind(); it.Valid(); it.Next() {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// deleteKeysWithPrefix collects the matching keys first and then deletes
+// them in a write batch, since deleting while iterating is unsafe
+func (s *BadgerService) deleteKeysWithPrefix(prefix []byte) (int, error) {
+	var keys [][]byte
+	err := s.view(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		
+		for it.Rewind(); it.Valid(); it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	
+	wb := s.db.NewWriteBatch()
+	defer wb.Cancel()
+	
+	for _, key := range keys {
+		if err := wb.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	
+	if err := wb.Flush(); err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
+
+// resetCounter restarts ID allocation for entity from 1
+func (s *BadgerService) resetCounter(entity string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	err := s.writeTxn(func(txn *badger.Txn) error {
+		return txn.Delete(s.counterKey(entity))
+	})
+	if err != nil {
+		return err
+	}
+	
+	s.counters[entity] = 0
+	return nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestDeletePrefix(t *testing.T) {
+	s := newTestService(t, WithExistenceFilter(100))
+	
+	n, err := s.DeletePrefix("users:")
+	if err != nil {
+		t.Fatalf("DeletePrefix: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("deleted %d keys, want 3", n)
+	}
+	
+	for id := int64(1); id <= 3; id++ {
+		if s.ProbablyExists("users", id) {
+			t.Errorf("ProbablyExists(users, %d) = true after DeletePrefix", id)
+		}
+	}
+	if count, err := s.Count("users"); err != nil || count != 0 {
+		t.Errorf("Count = %d, %v, want 0", count, err)
+	}
+	
+	// The counter starts over
+	user := &User{Name: "Dana", Email: "dana@example.com", CompanyID: 1}
+	if err := s.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if user.ID != 1 {
+		t.Errorf("new user got ID %d, want 1", user.ID)
+	}
+}
+
+func TestDeleteWhere(t *testing.T) {
+	s := newTestService(t, WithExistenceFilter(100))
+	
+	n, err := s.DeleteWhere("orders", func(record json.RawMessage) bool {
+		var order Order
+		return json.Unmarshal(record, &order) == nil && order.Status == "completed"
+	})
+	if err != nil {
+		t.Fatalf("DeleteWhere: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("deleted %d orders, want 3", n)
+	}
+	
+	for _, id := range []int64{1, 2, 4} {
+		if s.ProbablyExists("orders", id) {
+			t.Errorf("ProbablyExists(orders, %d) = true after DeleteWhere", id)
+		}
+	}
+	if !s.ProbablyExists("orders", 3) {
+		t.Error("ProbablyExists(orders, 3) = false for the kept order")
+	}
+	if count, err := s.Count("orders"); err != nil || count != 1 {
+		t.Errorf("Count = %d, %v, want 1", count, err)
+	}
+	
+	events, err := s.ListAuditEvents(time.Time{})
+	if err != nil {
+		t.Fatalf("ListAuditEvents: %v", err)
+	}
+	deleted := make(map[int64]bool)
+	for _, e := range events {
+		if e.Op == "delete" && e.Entity == "orders" {
+			deleted[e.EntityID] = true
+		}
+	}
+	if len(deleted) != 3 || !deleted[1] || !deleted[2] || !deleted[4] {
+		t.Errorf("delete audit events for orders %v, want 1, 2 and 4", deleted)
+	}
+}
+
+func TestDeleteWherePendingOrders(t *testing.T) {
+	s := newTestService(t)
+	
+	n, err := s.DeleteWhere("orders", func(record json.RawMessage) bool {
+		var order Order
+		return json.Unmarshal(record, &order) == nil && order.Status == "pending"
+	})
+	if err != nil {
+		t.Fatalf("DeleteWhere: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("deleted %d orders, want 1", n)
+	}
+	
+	orders, err := s.Orders().List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(orders) != 3 {
+		t.Fatalf("%d orders remain, want 3", len(orders))
+	}
+	for _, order := range orders {
+		if order.Status != "completed" {
+			t.Errorf("order %d is %s, want only completed orders to remain", order.ID, order.Status)
+		}
+	}
+	
+	// Alice's index entry for the deleted order is gone too
+	if containsID(indexedIDs(t, s, "orders", "user", "1"), 3) {
+		t.Error("the user index still lists deleted order 3")
+	}
+}
+
+// setRaw writes key=value as they are, as data from an older key schema
+func setRaw(t *testing.T, s *BadgerService, key, value string) {
+	t.Helper()
+	
+	err := s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(key), []byte(value))
+	})
+	if err != nil {
+		t.Fatalf("writing %s: %v", key, err)
+	}
+}
+
+func TestRenamePrefix(t *testing.T) {
+	s := newTestService(t)
+	
+	setRaw(t, s, "user:10", `{"id":10,"name":"Old Ten","email":"ten@example.com","company_id":1}`)
+	setRaw(t, s, "user:11", `{"id":11,"name":"Old Eleven","email":"eleven@example.com","company_id":2}`)
+	
+	n, err := s.RenamePrefix("user:", "users:", false)
+	if err != nil {
+		t.Fatalf("RenamePrefix: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("moved %d keys, want 2", n)
+	}
+	
+	if count, err := s.countPrefix([]byte("user:")); err != nil || count != 0 {
+		t.Errorf("%d keys left under user:, %v, want none", count, err)
+	}
+	moved, err := s.Users().Get(11)
+	if err != nil {
+		t.Fatalf("Get(11): %v", err)
+	}
+	if moved.Name != "Old Eleven" {
+		t.Errorf("Get(11) = %+v, want Old Eleven", moved)
+	}
+	if count, err := s.Count("users"); err != nil || count != 5 {
+		t.Errorf("Count = %d, %v, want the 3 seeded users and the 2 moved ones", count, err)
+	}
+}
+
+func TestRenamePrefixExistingKeys(t *testing.T) {
+	s := newTestService(t)
+	
+	setRaw(t, s, "user:1", `{"id":1,"name":"Old Alice","email":"old.alice@example.com","company_id":1}`)
+	setRaw(t, s, "user:12", `{"id":12,"name":"Old Twelve","email":"twelve@example.com","company_id":1}`)
+	
+	// Nothing is moved when one target exists
+	if _, err := s.RenamePrefix("user:", "users:", false); !errors.Is(err, ErrKeyExists) {
+		t.Fatalf("RenamePrefix = %v, want ErrKeyExists", err)
+	}
+	if count, err := s.countPrefix([]byte("user:")); err != nil || count != 2 {
+		t.Errorf("%d keys left under user:, %v, want both", count, err)
+	}
+	if _, err := s.Users().Get(12); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(12) = %v, want ErrNotFound", err)
+	}
+	
+	n, err := s.RenamePrefix("user:", "users:", true)
+	if err != nil || n != 2 {
+		t.Fatalf("RenamePrefix with overwrite = %d, %v, want 2", n, err)
+	}
+	alice, err := s.Users().Get(1)
+	if err != nil || alice.Name != "Old Alice" {
+		t.Errorf("Get(1) = %+v, %v, want the overwritten Old Alice", alice, err)
+	}
+	
+	if _, err := s.RenamePrefix("users:", "users:archive:", false); err == nil {
+		t.Error("overlapping prefixes were accepted")
+	}
+}
+
+func TestDeletePrefixDropsSoftDeleteMarkers(t *testing.T) {
+	s := newTestService(t)
+	
+	if err := s.SoftDelete("users", 1); err != nil {
+		t.Fatalf("SoftDelete: %v", err)
+	}
+	if _, err := s.DeletePrefix("users:"); err != nil {
+		t.Fatalf("DeletePrefix: %v", err)
+	}
+	
+	// The reset counter hands out ID 1 again, which must not come back hidden
+	user := &User{Name: "Dana", Email: "dana@example.com", CompanyID: 1}
+	if err := s.CreateUser(user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if user.ID != 1 {
+		t.Fatalf("new user got ID %d, want 1", user.ID)
+	}
+	if got, err := s.Users().Get(1); err != nil || got.Name != "Dana" {
+		t.Errorf("Get(1) = %+v, %v, want Dana", got, err)
+	}
+	if count, err := s.countPrefix([]byte("deleted:users:")); err != nil || count != 0 {
+		t.Errorf("%d soft delete markers left, %v, want none", count, err)
+	}
+}
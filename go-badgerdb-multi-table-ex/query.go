@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Query filters, sorts and pages the records of one entity:
+//
+//	var users []User
+//	err := s.Query("users").
+//		Where(func(u User) bool { return u.CompanyID == 1 }).
+//		SortBy("name").
+//		Limit(10).
+//		Offset(5).
+//		All(&users)
+//
+// The records are read with a prefix scan and filtered in memory, so a query
+// costs a full scan of the entity.
+type Query struct {
+	s      *BadgerService
+	entity string
+	preds  []interface{}
+	sortBy string
+	desc   bool
+	limit  int
+	offset int
+}
+
+// Query starts a query over the records of entity
+func (s *BadgerService) Query(entity string) *Query {
+	return &Query{s: s, entity: entity}
+}
+
+// Where keeps only the records pred returns true for. pred must be a
+// func(T) bool where T is the record type passed to All. Several Where calls
+// must all match.
+func (q *Query) Where(pred interface{}) *Query {
+	q.preds = append(q.preds, pred)
+	return q
+}
+
+// SortBy orders the results by a field, named by its JSON tag such as
+// "created_at". Prefix the name with "-" to sort in descending order.
+// Strings, numbers, bools and time.Time fields can be sorted on.
+func (q *Query) SortBy(field string) *Query {
+	q.desc = strings.HasPrefix(field, "-")
+	q.sortBy = strings.TrimPrefix(field, "-")
+	return q
+}
+
+// Limit caps the number of results; 0 means no limit
+func (q *Query) Limit(n int) *Query {
+	q.limit = n
+	return q
+}
+
+// Offset skips the first n results, after filtering and sorting
+func (q *Query) Offset(n int) *Query {
+	q.offset = n
+	return q
+}
+
+// All runs the query and stores the results in result, which must point to
+// a []T
+func (q *Query) All(result interface{}) error {
+	ptr := reflect.ValueOf(result)
+	if ptr.Kind() != reflect.Pointer || ptr.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("query result must be a pointer to a slice, got %T", result)
+	}
+	elemType := ptr.Elem().Type().Elem()
+	
+	preds := make([]reflect.Value, 0, len(q.preds))
+	for _, pred := range q.preds {
+		fn := reflect.ValueOf(pred)
+		t := fn.Type()
+		if t.Kind() != reflect.Func || t.NumIn() != 1 || t.In(0) != elemType ||
+			t.NumOut() != 1 || t.Out(0).Kind() != reflect.Bool {
+			return fmt.Errorf("query predicate must be a func(%s) bool, got %T", elemType, pred)
+		}
+		preds = append(preds, fn)
+	}
+	
+	var less func(a, b reflect.Value) bool
+	if q.sortBy != "" {
+		field, ok := fieldByJSONName(elemType, q.sortBy)
+		if !ok {
+			return fmt.Errorf("%s has no field %q to sort by", elemType, q.sortBy)
+		}
+		if less = lessFunc(elemType.Field(field).Type); less == nil {
+			return fmt.Errorf("cannot sort by %q of type %s", q.sortBy, elemType.Field(field).Type)
+		}
+		
+		byField := less
+		less = func(a, b reflect.Value) bool {
+			return byField(a.Field(field), b.Field(field))
+		}
+	}
+	
+	all := reflect.New(ptr.Elem().Type())
+	if err := q.s.list(q.entity, all.Interface()); err != nil {
+		return err
+	}
+	items := all.Elem()
+	
+	matched := reflect.MakeSlice(items.Type(), 0, items.Len())
+	for i := 0; i < items.Len(); i++ {
+		item := items.Index(i)
+		keep := true
+		for _, pred := range preds {
+			if !pred.Call([]reflect.Value{item})[0].Bool() {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			matched = reflect.Append(matched, item)
+		}
+	}
+	
+	if less != nil {
+		sort.SliceStable(matched.Interface(), func(i, j int) bool {
+			if q.desc {
+				return less(matched.Index(j), matched.Index(i))
+			}
+			return less(matched.Index(i), matched.Index(j))
+		})
+	}
+	
+	start := min(q.offset, matched.Len())
+	end := matched.Len()
+	if q.limit > 0 {
+		end = min(start+q.limit, end)
+	}
+	
+	ptr.Elem().Set(matched.Slice(start, end))
+	return nil
+}
+
+// fieldByJSONName returns the index of the field of struct type t whose JSON
+// name is name
+func fieldByJSONName(t reflect.Type, name string) (int, bool) {
+	if t.Kind() != reflect.Struct {
+		return 0, false
+	}
+	
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if tag == name || (tag == "" && f.Name == name) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// lessFunc returns the ordering of values of type t, or nil if they cannot be
+// ordered
+func lessFunc(t reflect.Type) func(a, b reflect.Value) bool {
+	if t == timeType {
+		return func(a, b reflect.Value) bool {
+			return a.Interface().(time.Time).Before(b.Interface().(time.Time))
+		}
+	}
+	
+	switch t.Kind() {
+	case reflect.String:
+		return func(a, b reflect.Value) bool { return a.String() < b.String() }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(a, b reflect.Value) bool { return a.Int() < b.Int() }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(a, b reflect.Value) bool { return a.Uint() < b.Uint() }
+	case reflect.Float32, reflect.Float64:
+		return func(a, b reflect.Value) bool { return a.Float() < b.Float() }
+	case reflect.Bool:
+		return func(a, b reflect.Value) bool { return !a.Bool() && b.Bool() }
+	}
+	return nil
+}
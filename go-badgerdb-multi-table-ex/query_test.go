@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQueryWhereSortLimit(t *testing.T) {
+	s := newTestService(t)
+	
+	for _, name := range []string{"Zed", "Eve", "Dave"} {
+		user := &User{Name: name + " Doe", Email: strings.ToLower(name) + "@example.com", CompanyID: 1}
+		if err := s.CreateUser(user); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+	// Bob works for company 2 and must be filtered out
+	inCompany1 := func(u User) bool { return u.CompanyID == 1 }
+	
+	for _, tc := range []struct {
+		sortBy        string
+		limit, offset int
+		want          []string
+	}{
+		{"name", 3, 1, []string{"Charlie Brown", "Dave Doe", "Eve Doe"}},
+		{"-name", 2, 0, []string{"Zed Doe", "Eve Doe"}},
+		{"name", 0, 3, []string{"Eve Doe", "Zed Doe"}},
+		{"name", 10, 5, nil},
+	} {
+		var users []User
+		err := s.Query("users").Where(inCompany1).SortBy(tc.sortBy).Limit(tc.limit).Offset(tc.offset).All(&users)
+		if err != nil {
+			t.Fatalf("All: %v", err)
+		}
+		
+		var got []string
+		for _, u := range users {
+			got = append(got, u.Name)
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("sort %s limit %d offset %d: got %v, want %v", tc.sortBy, tc.limit, tc.offset, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("sort %s limit %d offset %d: got %v, want %v", tc.sortBy, tc.limit, tc.offset, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+func TestQueryMultipleWhere(t *testing.T) {
+	s := newTestService(t)
+	
+	var orders []Order
+	err := s.Query("orders").
+		Where(func(o Order) bool { return o.UserID == 1 }).
+		Where(func(o Order) bool { return o.Status == "completed" }).
+		All(&orders)
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if len(orders) != 1 || orders[0].ID != 1 {
+		t.Errorf("got %+v, want only Alice's completed order 1", orders)
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	s := newTestService(t)
+	
+	var users []User
+	if err := s.Query("users").Where(func(o Order) bool { return true }).All(&users); err == nil {
+		t.Error("a predicate over the wrong type was accepted")
+	}
+	if err := s.Query("users").SortBy("nickname").All(&users); err == nil {
+		t.Error("sorting by an unknown field was accepted")
+	}
+	if err := s.Query("users").All(users); err == nil {
+		t.Error("a slice that is not a pointer was accepted")
+	}
+}
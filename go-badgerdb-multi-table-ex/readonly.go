@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ErrReadOnly is returned by every write on a service opened with
+// NewReadOnlyBadgerService
+var ErrReadOnly = errors.New("badger service is read-only")
+
+// NewReadOnlyBadgerService opens an existing database without write access,
+// which makes it safe to run analytics against a copy of production data
+func NewReadOnlyBadgerService(dbPath string, opts ...Option) (*BadgerService, error) {
+	return NewBadgerService(dbPath, append(opts, WithReadOnly(true))...)
+}
+
+// checkWritable returns ErrServiceClosed or ErrReadOnly when the service may
+// not write
+func (s *BadgerService) checkWritable() error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// writeTxn runs fn in a read-write transaction, retrying on conflicts, or
+// fails with ErrReadOnly
+func (s *BadgerService) writeTxn(fn func(txn *badger.Txn) error) error {
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	return s.updateWithRetry(fn, defaultMaxRetries)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReadOnlyServiceRejectsWrites(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewBadgerService(dir)
+	if err != nil {
+		t.Fatalf("NewBadgerService: %v", err)
+	}
+	if err := setupTestData(s, false); err != nil {
+		t.Fatalf("setupTestData: %v", err)
+	}
+	s.Close()
+	
+	ro, err := NewReadOnlyBadgerService(dir)
+	if err != nil {
+		t.Fatalf("NewReadOnlyBadgerService: %v", err)
+	}
+	defer ro.Close()
+	
+	if err := ro.CreateUser(&User{Name: "Dana", Email: "dana@techcorp.com", CompanyID: 1}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("CreateUser: got %v, want ErrReadOnly", err)
+	}
+	alice, err := ro.Users().Get(1)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	alice.Name = "Alice Jones"
+	if err := ro.Users().Update(alice); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Update: got %v, want ErrReadOnly", err)
+	}
+	if err := ro.Users().Delete(1); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Delete: got %v, want ErrReadOnly", err)
+	}
+	
+	users, err := ro.Users().List()
+	if err != nil || len(users) != 3 || users[0].Name != "Alice Smith" {
+		t.Errorf("List: got %+v, %v, want the 3 seeded users unchanged", users, err)
+	}
+}
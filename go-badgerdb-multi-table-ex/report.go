@@ -0,0 +1,89 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// reportTopProducts is how many products per category GenerateReport ranks
+const reportTopProducts = 3
+
+// Report gathers the figures of a periodic report, all computed from the
+// same snapshot of the database
+type Report struct {
+	CompanyStats          []CompanyStats  `json:"company_stats"`
+	TopProductsByCategory []CategorySales `json:"top_products_by_category"`
+	OrphanOrderIDs        []int64         `json:"orphan_order_ids"`
+}
+
+// GenerateReport computes what GetCompanyStats,
+// GetTopSellingProductsByCategory(reportTopProducts) and FindOrphanedOrders
+// return, but lists every entity only once and does so in a single read
+// transaction, so the three parts agree with each other
+func (s *BadgerService) GenerateReport() (*Report, error) {
+	var (
+		companies  []Company
+		users      []User
+		orders     []Order
+		products   []Product
+		categories []Category
+	)
+	
+	err := s.view(func(txn *badger.Txn) error {
+		lists := []struct {
+			entity string
+			result interface{}
+		}{
+			{"companies", &companies},
+			{"users", &users},
+			{"orders", &orders},
+			{"products", &products},
+			{"categories", &categories},
+		}
+		for _, l := range lists {
+			if err := s.listTxn(txn, l.entity, l.result); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	productMap := make(map[int64]Product, len(products))
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+	
+	categoryMap := make(map[int64]Category, len(categories))
+	for _, category := range categories {
+		categoryMap[category.ID] = category
+	}
+	
+	existing := map[string]map[int64]bool{
+		"users":    make(map[int64]bool, len(users)),
+		"products": make(map[int64]bool, len(products)),
+	}
+	for _, user := range users {
+		existing["users"][user.ID] = true
+	}
+	for id := range productMap {
+		existing["products"][id] = true
+	}
+	
+	var orphans []int64
+	for i := range orders {
+		if referencesMissing(&orders[i], existing) {
+			orphans = append(orphans, orders[i].ID)
+		}
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i] < orphans[j] })
+	
+	return &Report{
+		CompanyStats:          companyStats(companies, users, orders, ""),
+		TopProductsByCategory: topSellingByCategory(orders, productMap, categoryMap, reportTopProducts),
+		OrphanOrderIDs:        orphans,
+	}, nil
+}
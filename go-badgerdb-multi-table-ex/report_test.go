@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerateReportMatchesTheIndividualMethods(t *testing.T) {
+	s := newTestService(t)
+	
+	// An orphaned order and a few more sales make every part non-trivial
+	extra := []*Order{
+		{UserID: 99, ProductID: 1, Quantity: 1, Amount: 999.99, Status: "completed"},
+		{UserID: 2, ProductID: 2, Quantity: 3, Amount: 149.97, Status: "completed"},
+		{UserID: 3, ProductID: 42, Quantity: 1, Amount: 5, Status: "pending"},
+	}
+	for _, order := range extra {
+		if err := s.CreateOrder(order); err != nil {
+			t.Fatalf("CreateOrder: %v", err)
+		}
+	}
+	
+	report, err := s.GenerateReport()
+	if err != nil {
+		t.Fatalf("GenerateReport: %v", err)
+	}
+	
+	stats, err := s.GetCompanyStats()
+	if err != nil {
+		t.Fatalf("GetCompanyStats: %v", err)
+	}
+	if !reflect.DeepEqual(report.CompanyStats, stats) {
+		t.Errorf("CompanyStats = %+v, want %+v", report.CompanyStats, stats)
+	}
+	
+	top, err := s.GetTopSellingProductsByCategory(reportTopProducts)
+	if err != nil {
+		t.Fatalf("GetTopSellingProductsByCategory: %v", err)
+	}
+	if !reflect.DeepEqual(report.TopProductsByCategory, top) {
+		t.Errorf("TopProductsByCategory = %+v, want %+v", report.TopProductsByCategory, top)
+	}
+	
+	orphans, err := s.FindOrphanedOrders()
+	if err != nil {
+		t.Fatalf("FindOrphanedOrders: %v", err)
+	}
+	if !reflect.DeepEqual(report.OrphanOrderIDs, orphans) {
+		t.Errorf("OrphanOrderIDs = %v, want %v", report.OrphanOrderIDs, orphans)
+	}
+	if len(orphans) != 2 || orphans[0] != extra[0].ID || orphans[1] != extra[2].ID {
+		t.Errorf("orphans = %v, want orders %d and %d", orphans, extra[0].ID, extra[2].ID)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+// Repository provides the CRUD operations for one entity type. The records
+// are stored under "<entity>:<id>" and id returns a pointer to the ID field
+// of a record, so Create can assign it.
+type Repository[T any] struct {
+	s      *BadgerService
+	entity string
+	id     func(*T) *int64
+}
+
+func NewRepository[T any](s *BadgerService, entity string, id func(*T) *int64) *Repository[T] {
+	return &Repository[T]{s: s, entity: entity, id: id}
+}
+
+// Create assigns the next ID to item and stores it
+func (r *Repository[T]) Create(item *T) error {
+	if err := r.s.checkWritable(); err != nil {
+		return err
+	}
+	
+	id, err := r.s.getNextID(r.entity)
+	if err != nil {
+		return err
+	}
+	*r.id(item) = id
+	return r.s.create(r.entity, id, item)
+}
+
+func (r *Repository[T]) Get(id int64) (*T, error) {
+	var item T
+	if err := r.s.get(r.entity, id, &item); err != nil {
+		return nil, err
+	}
+	
+	return &item, nil
+}
+
+// Update overwrites an existing record, it fails if the ID is unknown
+func (r *Repository[T]) Update(item *T) error {
+	return r.s.update(r.entity, *r.id(item), item)
+}
+
+func (r *Repository[T]) Delete(id int64) error {
+	return r.s.delete(r.entity, id)
+}
+
+func (r *Repository[T]) List() ([]T, error) {
+	var items []T
+	if err := r.s.list(r.entity, &items); err != nil {
+		return nil, err
+	}
+	
+	return items, nil
+}
+
+// Repositories for the built-in entities
+func (s *BadgerService) Users() *Repository[User] {
+	return NewRepository(s, "users", func(u *User) *int64 { return &u.ID })
+}
+
+func (s *BadgerService) Companies() *Repository[Company] {
+	return NewRepository(s, "companies", func(c *Company) *int64 { return &c.ID })
+}
+
+func (s *BadgerService) Orders() *Repository[Order] {
+	return NewRepository(s, "orders", func(o *Order) *int64 { return &o.ID })
+}
+
+func (s *BadgerService) Products() *Repository[Product] {
+	return NewRepository(s, "products", func(p *Product) *int64 { return &p.ID })
+}
+
+func (s *BadgerService) Categories() *Repository[Category] {
+	return NewRepository(s, "categories", func(c *Category) *int64 { return &c.ID })
+}
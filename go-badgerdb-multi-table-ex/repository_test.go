@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRepository(t *testing.T) {
+	s := newTestService(t)
+	
+	users := NewRepository(s, "users", func(u *User) *int64 { return &u.ID })
+	orders := NewRepository(s, "orders", func(o *Order) *int64 { return &o.ID })
+	
+	user := &User{Name: "Dana White", Email: "dana@techcorp.com", CompanyID: 1}
+	if err := users.Create(user); err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	order := &Order{UserID: user.ID, ProductID: 2, Quantity: 1, Amount: 49.99, Status: "pending"}
+	if err := orders.Create(order); err != nil {
+		t.Fatalf("Create order: %v", err)
+	}
+	if user.ID != 4 || order.ID != 5 {
+		t.Errorf("got user %d and order %d, want 4 and 5 after the seed data", user.ID, order.ID)
+	}
+	
+	order.Status = "completed"
+	if err := orders.Update(order); err != nil {
+		t.Fatalf("Update order: %v", err)
+	}
+	got, err := orders.Get(order.ID)
+	if err != nil {
+		t.Fatalf("Get order: %v", err)
+	}
+	if got.UserID != user.ID || got.Status != "completed" {
+		t.Errorf("got order %+v, want the completed order of user %d", got, user.ID)
+	}
+	
+	if err := orders.Delete(order.ID); err != nil {
+		t.Fatalf("Delete order: %v", err)
+	}
+	if _, err := orders.Get(order.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted order: got %v, want ErrNotFound", err)
+	}
+	
+	all, err := users.List()
+	if err != nil {
+		t.Fatalf("List users: %v", err)
+	}
+	if len(all) != 4 || all[3].Name != "Dana White" {
+		t.Errorf("got users %+v, want Dana after the 3 seeded ones", all)
+	}
+	
+	// Updating an unknown ID fails instead of creating the record
+	if err := users.Update(&User{ID: 42, Name: "Nobody"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update unknown user: got %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Backoff between attempts of a conflicting transaction: the delay doubles
+// from retryBaseDelay up to retryMaxDelay, and a random jitter of up to the
+// same amount keeps concurrent writers from retrying in lockstep
+const (
+	defaultMaxRetries = 5
+	retryBaseDelay    = 2 * time.Millisecond
+	retryMaxDelay     = 200 * time.Millisecond
+)
+
+// updateWithRetry runs fn in a read-write transaction, running it again in a
+// fresh transaction if the commit fails with badger.ErrConflict, up to
+// maxRetries times. fn must therefore be safe to run more than once. Any
+// other error is returned straight away.
+func (s *BadgerService) updateWithRetry(fn func(*badger.Txn) error, maxRetries int) error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	
+	delay := retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		err := s.db.Update(fn)
+		if !errors.Is(err, badger.ErrConflict) || attempt >= maxRetries {
+			return err
+		}
+		
+		time.Sleep(delay + rand.N(delay))
+		delay = min(delay*2, retryMaxDelay)
+	}
+}
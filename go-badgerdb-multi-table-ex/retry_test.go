@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// conflictOnce returns a transaction function that reads key and, on its
+// first run only, has another transaction write key before it does, so the
+// first commit fails with badger.ErrConflict
+func conflictOnce(t *testing.T, s *BadgerService, key []byte, runs *int) func(*badger.Txn) error {
+	return func(txn *badger.Txn) error {
+		*runs++
+		if _, err := txn.Get(key); err != nil && err != badger.ErrKeyNotFound {
+			return err
+		}
+		if *runs == 1 {
+			err := s.db.Update(func(other *badger.Txn) error {
+				return other.Set(key, []byte("other"))
+			})
+			if err != nil {
+				t.Errorf("concurrent write: %v", err)
+			}
+		}
+		return txn.Set(key, []byte("mine"))
+	}
+}
+
+func TestUpdateWithRetryRetriesConflicts(t *testing.T) {
+	s := newTestService(t)
+	key := []byte("retry-test")
+	
+	runs := 0
+	if err := s.updateWithRetry(conflictOnce(t, s, key, &runs), defaultMaxRetries); err != nil {
+		t.Fatalf("updateWithRetry: %v", err)
+	}
+	if runs != 2 {
+		t.Errorf("fn ran %d times, want 2", runs)
+	}
+	
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			if string(val) != "mine" {
+				t.Errorf("key holds %q, want the retried write", val)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("reading the key: %v", err)
+	}
+}
+
+func TestUpdateWithRetryGivesUp(t *testing.T) {
+	s := newTestService(t)
+	
+	runs := 0
+	err := s.updateWithRetry(conflictOnce(t, s, []byte("retry-test"), &runs), 0)
+	if !errors.Is(err, badger.ErrConflict) {
+		t.Errorf("updateWithRetry = %v, want ErrConflict", err)
+	}
+	if runs != 1 {
+		t.Errorf("fn ran %d times, want 1", runs)
+	}
+}
+
+func TestUpdateWithRetryConcurrentIncrements(t *testing.T) {
+	s := newTestService(t)
+	key := []byte("retry-counter")
+	
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			
+			err := s.updateWithRetry(func(txn *badger.Txn) error {
+				var n int64
+				item, err := txn.Get(key)
+				if err == nil {
+					err = item.Value(func(val []byte) error {
+						n = decodeCounter(val)
+						return nil
+					})
+				}
+				if err != nil && err != badger.ErrKeyNotFound {
+					return err
+				}
+				return txn.Set(key, encodeCounter(n+1))
+			}, 100)
+			if err != nil {
+				t.Errorf("updateWithRetry: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	
+	var n int64
+	err := s.db.View(func(txn *badger.Txn) error {
+		var err error
+		n, err = readLiveCount(txn, key)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("reading the counter: %v", err)
+	}
+	if n != writers {
+		t.Errorf("counter = %d, want %d: an increment was lost", n, writers)
+	}
+}
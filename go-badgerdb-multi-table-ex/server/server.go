@@ -0,0 +1,190 @@
+// Package server exposes a record store over HTTP. Request and response
+// bodies are JSON; results are encoded exactly as the store returns them.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Errors a Store wraps to pick the HTTP status of a failed request. Any
+// other error is reported as 500 Internal Server Error.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrBadRequest = errors.New("bad request")
+)
+
+// shutdownTimeout bounds how long in-flight requests may take to finish
+// once the server is asked to stop
+const shutdownTimeout = 5 * time.Second
+
+// maxBodySize limits the size of a request body
+const maxBodySize = 1 << 20
+
+// Store is what the server needs from the database. entity is the plural
+// name used in the URL, such as "users"; an unknown entity should fail with
+// ErrNotFound.
+type Store interface {
+	Create(entity string, body json.RawMessage) (interface{}, error)
+	Get(entity string, id int64) (interface{}, error)
+	Update(entity string, id int64, body json.RawMessage) (interface{}, error)
+	Delete(entity string, id int64) error
+	List(entity string) (interface{}, error)
+	OrdersWithDetails() (interface{}, error)
+	CompanyStats() (interface{}, error)
+}
+
+// NewHandler returns the routes of the API:
+//
+//	GET    /companies/stats
+//	GET    /orders/details
+//	GET    /{entity}
+//	POST   /{entity}
+//	GET    /{entity}/{id}
+//	PUT    /{entity}/{id}
+//	DELETE /{entity}/{id}
+func NewHandler(store Store) http.Handler {
+	mux := http.NewServeMux()
+	
+	mux.HandleFunc("GET /companies/stats", func(w http.ResponseWriter, r *http.Request) {
+		result, err := store.CompanyStats()
+		respond(w, http.StatusOK, result, err)
+	})
+	mux.HandleFunc("GET /orders/details", func(w http.ResponseWriter, r *http.Request) {
+		result, err := store.OrdersWithDetails()
+		respond(w, http.StatusOK, result, err)
+	})
+	
+	mux.HandleFunc("GET /{entity}", func(w http.ResponseWriter, r *http.Request) {
+		result, err := store.List(r.PathValue("entity"))
+		respond(w, http.StatusOK, result, err)
+	})
+	mux.HandleFunc("POST /{entity}", func(w http.ResponseWriter, r *http.Request) {
+		body, err := readBody(r)
+		if err != nil {
+			respond(w, 0, nil, err)
+			return
+		}
+		result, err := store.Create(r.PathValue("entity"), body)
+		respond(w, http.StatusCreated, result, err)
+	})
+	
+	mux.HandleFunc("GET /{entity}/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := pathID(r)
+		if err != nil {
+			respond(w, 0, nil, err)
+			return
+		}
+		result, err := store.Get(r.PathValue("entity"), id)
+		respond(w, http.StatusOK, result, err)
+	})
+	mux.HandleFunc("PUT /{entity}/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := pathID(r)
+		if err != nil {
+			respond(w, 0, nil, err)
+			return
+		}
+		body, err := readBody(r)
+		if err != nil {
+			respond(w, 0, nil, err)
+			return
+		}
+		result, err := store.Update(r.PathValue("entity"), id, body)
+		respond(w, http.StatusOK, result, err)
+	})
+	mux.HandleFunc("DELETE /{entity}/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := pathID(r)
+		if err != nil {
+			respond(w, 0, nil, err)
+			return
+		}
+		if err := store.Delete(r.PathValue("entity"), id); err != nil {
+			respond(w, 0, nil, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	
+	return mux
+}
+
+// ListenAndServe serves the API on addr until ctx is cancelled, then stops
+// accepting connections and waits for in-flight requests to finish
+func ListenAndServe(ctx context.Context, addr string, store Store) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           NewHandler(store),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+	
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	
+	if err := <-errc; err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func pathID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid id %q", ErrBadRequest, r.PathValue("id"))
+	}
+	return id, nil
+}
+
+func readBody(r *http.Request) (json.RawMessage, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxBodySize {
+		return nil, fmt.Errorf("%w: body larger than %d bytes", ErrBadRequest, maxBodySize)
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("%w: body is not valid JSON", ErrBadRequest)
+	}
+	return body, nil
+}
+
+// respond writes result as JSON with status, or the error response for err
+func respond(w http.ResponseWriter, status int, result interface{}, err error) {
+	if err != nil {
+		status = http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, ErrBadRequest):
+			status = http.StatusBadRequest
+		}
+		result = map[string]string{"error": err.Error()}
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Printf("server: writing response: %v", err)
+	}
+}
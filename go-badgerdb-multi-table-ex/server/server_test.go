@@ -0,0 +1,152 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeStore serves a fixed set of users and records what it was asked to do
+type fakeStore struct {
+	users   map[int64]map[string]interface{}
+	created json.RawMessage
+	deleted int64
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{users: map[int64]map[string]interface{}{
+		1: {"id": float64(1), "name": "Alice"},
+		2: {"id": float64(2), "name": "Bob"},
+	}}
+}
+
+func (f *fakeStore) check(entity string) error {
+	if entity != "users" {
+		return fmt.Errorf("%w: unknown entity %s", ErrNotFound, entity)
+	}
+	return nil
+}
+
+func (f *fakeStore) Create(entity string, body json.RawMessage) (interface{}, error) {
+	if err := f.check(entity); err != nil {
+		return nil, err
+	}
+	f.created = body
+	return map[string]interface{}{"id": float64(3)}, nil
+}
+
+func (f *fakeStore) Get(entity string, id int64) (interface{}, error) {
+	if err := f.check(entity); err != nil {
+		return nil, err
+	}
+	user, ok := f.users[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: user %d", ErrNotFound, id)
+	}
+	return user, nil
+}
+
+func (f *fakeStore) Update(entity string, id int64, body json.RawMessage) (interface{}, error) {
+	return f.Get(entity, id)
+}
+
+func (f *fakeStore) Delete(entity string, id int64) error {
+	if _, err := f.Get(entity, id); err != nil {
+		return err
+	}
+	f.deleted = id
+	return nil
+}
+
+func (f *fakeStore) List(entity string) (interface{}, error) {
+	if err := f.check(entity); err != nil {
+		return nil, err
+	}
+	return []interface{}{f.users[1], f.users[2]}, nil
+}
+
+func (f *fakeStore) OrdersWithDetails() (interface{}, error) {
+	return []interface{}{map[string]interface{}{"order": map[string]interface{}{"id": float64(1)}}}, nil
+}
+
+func (f *fakeStore) CompanyStats() (interface{}, error) {
+	return []interface{}{map[string]interface{}{"company_name": "Tech Corp"}}, nil
+}
+
+func TestHandler(t *testing.T) {
+	store := newFakeStore()
+	handler := NewHandler(store)
+	
+	tests := []struct {
+		method, path, body string
+		status             int
+		want               interface{}
+	}{
+		{"GET", "/users/1", "", http.StatusOK, store.users[1]},
+		{"GET", "/users", "", http.StatusOK, []interface{}{store.users[1], store.users[2]}},
+		{"GET", "/orders/details", "", http.StatusOK, must(store.OrdersWithDetails())},
+		{"GET", "/companies/stats", "", http.StatusOK, must(store.CompanyStats())},
+		{"POST", "/users", `{"name":"Carol"}`, http.StatusCreated, map[string]interface{}{"id": float64(3)}},
+		{"PUT", "/users/2", `{"name":"Bob"}`, http.StatusOK, store.users[2]},
+		{"GET", "/users/9", "", http.StatusNotFound, nil},
+		{"GET", "/widgets", "", http.StatusNotFound, nil},
+		{"GET", "/users/abc", "", http.StatusBadRequest, nil},
+		{"POST", "/users", `{"name":`, http.StatusBadRequest, nil},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		
+		if rec.Code != tc.status {
+			t.Errorf("%s %s: status %d, want %d", tc.method, tc.path, rec.Code, tc.status)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s %s: Content-Type %q", tc.method, tc.path, ct)
+		}
+		
+		var got interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Errorf("%s %s: invalid JSON %q: %v", tc.method, tc.path, rec.Body, err)
+			continue
+		}
+		if tc.want == nil {
+			if m, ok := got.(map[string]interface{}); !ok || m["error"] == nil {
+				t.Errorf("%s %s: got %v, want an error body", tc.method, tc.path, got)
+			}
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%s %s: got %v, want %v", tc.method, tc.path, got, tc.want)
+		}
+	}
+	
+	if string(store.created) != `{"name":"Carol"}` {
+		t.Errorf("store got create body %s", store.created)
+	}
+}
+
+func TestHandlerDelete(t *testing.T) {
+	store := newFakeStore()
+	rec := httptest.NewRecorder()
+	NewHandler(store).ServeHTTP(rec, httptest.NewRequest("DELETE", "/users/2", nil))
+	
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Errorf("status %d with body %q, want 204 and no body", rec.Code, rec.Body)
+	}
+	if store.deleted != 2 {
+		t.Errorf("deleted %d, want 2", store.deleted)
+	}
+}
+
+// must returns the result of a fake store call, which never fails
+func must(result interface{}, err error) interface{} {
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ErrInvalidKey is returned for a string key that is empty, contains the key
+// separator or is a number, which would clash with the auto-increment IDs
+var ErrInvalidKey = errors.New("invalid record key")
+
+// Records can also be stored under a string key such as a slug, e.g.
+// "categories:electronics". list returns them with the other records of the
+// entity, but having no numeric ID they are not indexed, audited or checked
+// for foreign keys, and whatever works with IDs (ID counters, Verify,
+// DeleteWhere) skips them.
+//
+// Keys from Repository.CreateGenerated, such as UUIDs, are stored under
+// "gen:<entity>:<key>" instead, outside the entity's prefix, so the records
+// never turn up in list, the joins or the counts and are only read back with
+// Repository.GetByKey.
+
+// checkStrKey rejects a string key that could clash with an ID or another key
+func (s *BadgerService) checkStrKey(key string) error {
+	if _, err := strconv.ParseInt(key, 10, 64); err == nil || key == "" || strings.IndexByte(key, s.sep) >= 0 {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
+}
+
+// strKey returns the key of the record stored under key
+func (s *BadgerService) strKey(entity, key string) ([]byte, error) {
+	if err := s.checkStrKey(key); err != nil {
+		return nil, err
+	}
+	return s.scopedKey(s.joinKey(entity, key)), nil
+}
+
+// genKey returns the key of the record stored under a generated key
+func (s *BadgerService) genKey(entity, key string) ([]byte, error) {
+	if err := s.checkStrKey(key); err != nil {
+		return nil, err
+	}
+	return s.scopedKey(s.joinKey("gen", entity, key)), nil
+}
+
+// createStr stores data under a string key, overwriting any record already
+// stored there
+func (s *BadgerService) createStr(entity, key string, data interface{}) error {
+	recordKey, err := s.strKey(entity, key)
+	if err != nil {
+		return err
+	}
+	return s.putStr(entity, recordKey, true, data)
+}
+
+// createGen stores data under a generated key. The record is not counted as
+// one of the entity's.
+func (s *BadgerService) createGen(entity, key string, data interface{}) error {
+	recordKey, err := s.genKey(entity, key)
+	if err != nil {
+		return err
+	}
+	return s.putStr(entity, recordKey, false, data)
+}
+
+// putStr stores data at recordKey, adjusting the live count of entity for a
+// new record if counted is set
+func (s *BadgerService) putStr(entity string, recordKey []byte, counted bool, data interface{}) error {
+	start := time.Now()
+	err := s.writeTxn(func(txn *badger.Txn) error {
+		value, err := s.marshalRecord(data)
+		if err != nil {
+			return err
+		}
+		
+		// Validators always see JSON
+		jsonData := value
+		if _, ok := s.codec.(JSONCodec); !ok {
+			if jsonData, err = json.Marshal(data); err != nil {
+				return err
+			}
+		}
+		if err := s.validate(entity, jsonData); err != nil {
+			return err
+		}
+		
+		if counted {
+			_, err = txn.Get(recordKey)
+			if err == badger.ErrKeyNotFound {
+				if err := s.adjustLiveCount(txn, entity, 1); err != nil {
+					return err
+				}
+			} else if err != nil {
+				return err
+			}
+		}
+		
+		return txn.Set(recordKey, value)
+	})
+	s.observe("create", entity, 0, start, err)
+	if err == nil {
+		s.invalidateStatsFor(entity)
+	}
+	
+	return err
+}
+
+// getStr reads the record stored under a string key
+func (s *BadgerService) getStr(entity, key string, result interface{}) error {
+	recordKey, err := s.strKey(entity, key)
+	if err != nil {
+		return err
+	}
+	return s.readStr(entity, key, recordKey, result)
+}
+
+// getGen reads the record stored under a generated key
+func (s *BadgerService) getGen(entity, key string, result interface{}) error {
+	recordKey, err := s.genKey(entity, key)
+	if err != nil {
+		return err
+	}
+	return s.readStr(entity, key, recordKey, result)
+}
+
+// readStr reads the record of entity stored at recordKey under key
+func (s *BadgerService) readStr(entity, key string, recordKey []byte, result interface{}) error {
+	start := time.Now()
+	err := s.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(recordKey)
+		if err == badger.ErrKeyNotFound {
+			return fmt.Errorf("%s %q: %w (%w)", entity, key, ErrNotFound, err)
+		}
+		if err != nil {
+			return err
+		}
+		
+		return item.Value(func(val []byte) error {
+			return s.codec.Unmarshal(val, result)
+		})
+	})
+	s.observe("get", entity, 0, start, err)
+	
+	return err
+}
+
+// CreateCategoryBySlug stores category under "categories:<slug>" instead of
+// a new ID. The category's ID is left as is.
+func (s *BadgerService) CreateCategoryBySlug(slug string, category *Category) error {
+	return s.createStr("categories", slug, category)
+}
+
+// GetCategoryBySlug reads a category stored with CreateCategoryBySlug
+func (s *BadgerService) GetCategoryBySlug(slug string) (*Category, error) {
+	var category Category
+	if err := s.getStr("categories", slug, &category); err != nil {
+		return nil, err
+	}
+	
+	return &category, nil
+}
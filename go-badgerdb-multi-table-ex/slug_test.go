@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCategoryBySlug(t *testing.T) {
+	s := newTestService(t)
+	
+	if err := s.CreateCategoryBySlug("gadgets", &Category{Name: "Gadgets"}); err != nil {
+		t.Fatalf("CreateCategoryBySlug: %v", err)
+	}
+	
+	got, err := s.GetCategoryBySlug("gadgets")
+	if err != nil {
+		t.Fatalf("GetCategoryBySlug: %v", err)
+	}
+	if got.Name != "Gadgets" {
+		t.Errorf("GetCategoryBySlug = %+v, want Gadgets", got)
+	}
+	if _, err := s.GetCategoryBySlug("toys"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetCategoryBySlug(toys) = %v, want ErrNotFound", err)
+	}
+	
+	// The prefix scan returns it with the numbered categories
+	categories, err := s.Categories().List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, c := range categories {
+		names[c.Name] = true
+	}
+	if len(categories) != 4 || !names["Gadgets"] || !names["Electronics"] {
+		t.Errorf("List = %+v, want the 3 seeded categories and Gadgets", categories)
+	}
+	
+	// The slug takes no ID
+	category := &Category{Name: "Toys"}
+	if err := s.CreateCategory(category); err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	if category.ID != 4 {
+		t.Errorf("next category got ID %d, want 4", category.ID)
+	}
+}
+
+func TestCategoryBySlugRejectsInvalidKeys(t *testing.T) {
+	s := newTestService(t)
+	
+	for _, slug := range []string{"", "12", "home:garden"} {
+		if err := s.CreateCategoryBySlug(slug, &Category{Name: "Invalid"}); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("CreateCategoryBySlug(%q) = %v, want ErrInvalidKey", slug, err)
+		}
+	}
+}
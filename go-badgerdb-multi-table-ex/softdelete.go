@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// A soft-deleted record stays stored but is hidden from get and list. Its
+// DeletedAt time is kept in a marker under "deleted:<entity>:<id>", prefixed
+// with the tenant if the service has one, so the record types need no extra
+// field. PurgeSoftDeleted removes the records for good.
+
+func (s *BadgerService) softDeleteKey(entity string, id int64) []byte {
+	return s.scopedKey(s.joinKey("deleted", entity, strconv.FormatInt(id, 10)))
+}
+
+// SoftDelete hides record id of entity from reads without removing it. The
+// record keeps its index entries, until RebuildIndexes drops them, and still
+// counts towards Count until it is purged.
+func (s *BadgerService) SoftDelete(entity string, id int64) error {
+	if _, ok := entityTypes[entity]; !ok {
+		return fmt.Errorf("no record type registered for %s", entity)
+	}
+	
+	start := time.Now()
+	err := s.writeTxn(func(txn *badger.Txn) error {
+		_, err := txn.Get(s.recordKey(entity, id))
+		if err == badger.ErrKeyNotFound {
+			return notFound(entity, id, err)
+		}
+		if err != nil {
+			return err
+		}
+		
+		deletedAt := s.nowFn().UTC().Format(time.RFC3339Nano)
+		return txn.Set(s.softDeleteKey(entity, id), []byte(deletedAt))
+	})
+	s.observe("soft_delete", entity, id, start, err)
+	if err == nil {
+		s.invalidateStatsFor(entity)
+	}
+	
+	return err
+}
+
+// isSoftDeleted reports whether record id of entity is soft-deleted
+func (s *BadgerService) isSoftDeleted(txn *badger.Txn, entity string, id int64) (bool, error) {
+	_, err := txn.Get(s.softDeleteKey(entity, id))
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// softDeletedIDs returns the soft-deleted records of entity with their
+// DeletedAt times
+func (s *BadgerService) softDeletedIDs(txn *badger.Txn, entity string) (map[int64]time.Time, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = s.scopedKey(s.joinKey("deleted", entity, ""))
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	
+	deleted := make(map[int64]time.Time)
+	for it.Rewind(); it.Valid(); it.Next() {
+		item := it.Item()
+		id, err := strconv.ParseInt(string(item.Key()[len(opts.Prefix):]), 10, 64)
+		if err != nil {
+			continue
+		}
+		
+		err = item.Value(func(val []byte) error {
+			deletedAt, err := time.Parse(time.RFC3339Nano, string(val))
+			deleted[id] = deletedAt
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return deleted, nil
+}
+
+// liveIDs returns the set of IDs of the records of entity that are not
+// soft-deleted
+func (s *BadgerService) liveIDs(txn *badger.Txn, entity string) (map[int64]bool, error) {
+	ids, err := storedIDs(txn, s.entityPrefix(entity))
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := s.softDeletedIDs(txn, entity)
+	if err != nil {
+		return nil, err
+	}
+	
+	for id := range deleted {
+		delete(ids, id)
+	}
+	return ids, nil
+}
+
+// isSoftDeletedKey reports whether key, a record key under prefix, belongs to
+// one of the deleted records returned by softDeletedIDs
+func isSoftDeletedKey(deleted map[int64]time.Time, key, prefix []byte) bool {
+	if len(deleted) == 0 {
+		return false
+	}
+	id, err := strconv.ParseInt(string(key[len(prefix):]), 10, 64)
+	_, ok := deleted[id]
+	return err == nil && ok
+}
+
+// PurgeSoftDeleted removes the records of every entity that were soft-deleted
+// more than olderThan ago, together with their index entries, and returns how
+// many were removed. It can be run on demand or from a ticker, as StartGC
+// does for value log GC.
+func (s *BadgerService) PurgeSoftDeleted(olderThan time.Duration) (int, error) {
+	if err := s.checkWritable(); err != nil {
+		return 0, err
+	}
+	
+	cutoff := s.nowFn().Add(-olderThan)
+	purged := 0
+	for entity := range entityTypes {
+		var ids []int64
+		err := s.view(func(txn *badger.Txn) error {
+			deleted, err := s.softDeletedIDs(txn, entity)
+			for id, deletedAt := range deleted {
+				if deletedAt.Before(cutoff) {
+					ids = append(ids, id)
+				}
+			}
+			return err
+		})
+		if err != nil {
+			return purged, err
+		}
+		
+		for _, id := range ids {
+			if err := s.delete(entity, id); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+	}
+	
+	return purged, nil
+}
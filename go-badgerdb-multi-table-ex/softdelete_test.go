@@ -0,0 +1,78 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSoftDeletedRecordsAreHiddenFromScans(t *testing.T) {
+	s := newTestService(t)
+	
+	// Fashion Ltd hides Bob, Charlie orphans order 4 and order 3 goes away
+	for _, d := range []struct {
+		entity string
+		id     int64
+	}{
+		{"companies", 2},
+		{"users", 3},
+		{"orders", 3},
+	} {
+		if err := s.SoftDelete(d.entity, d.id); err != nil {
+			t.Fatalf("SoftDelete(%s, %d): %v", d.entity, d.id, err)
+		}
+	}
+	
+	industries, err := s.DistinctStringField("companies", "industry")
+	if err != nil {
+		t.Fatalf("DistinctStringField: %v", err)
+	}
+	if want := []string{"Retail", "Technology"}; !reflect.DeepEqual(industries, want) {
+		t.Errorf("industries = %v, want %v", industries, want)
+	}
+	
+	total, err := s.Aggregate("orders", "amount", AggSum)
+	if err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+	if want := 999.99 + 39.98 + 999.99; !almostEqual(total, want) {
+		t.Errorf("order total = %v, want %v", total, want)
+	}
+	
+	joined, err := s.GetUsersWithCompanies()
+	if err != nil {
+		t.Fatalf("GetUsersWithCompanies: %v", err)
+	}
+	concurrent, err := s.GetUsersWithCompaniesConcurrent(2)
+	if err != nil {
+		t.Fatalf("GetUsersWithCompaniesConcurrent: %v", err)
+	}
+	if len(concurrent) != 1 || concurrent[0].User.ID != 1 {
+		t.Errorf("concurrent join = %v, want only Alice", concurrent)
+	}
+	if !reflect.DeepEqual(concurrent, joined) {
+		t.Errorf("concurrent join %v differs from %v", concurrent, joined)
+	}
+	
+	orphans, err := s.FindOrphanedOrders()
+	if err != nil {
+		t.Fatalf("FindOrphanedOrders: %v", err)
+	}
+	if want := []int64{4}; !reflect.DeepEqual(orphans, want) {
+		t.Errorf("orphans = %v, want %v", orphans, want)
+	}
+	
+	report, err := s.GenerateReport()
+	if err != nil {
+		t.Fatalf("GenerateReport: %v", err)
+	}
+	stats, err := s.GetCompanyStats()
+	if err != nil {
+		t.Fatalf("GetCompanyStats: %v", err)
+	}
+	if !reflect.DeepEqual(report.OrphanOrderIDs, orphans) {
+		t.Errorf("report orphans = %v, want %v", report.OrphanOrderIDs, orphans)
+	}
+	if !reflect.DeepEqual(report.CompanyStats, stats) {
+		t.Errorf("report company stats = %v, want %v", report.CompanyStats, stats)
+	}
+}
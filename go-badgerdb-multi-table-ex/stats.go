@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// DBStats reports the size and contents of the store
+type DBStats struct {
+	LSMSize      int64          `json:"lsm_size"`
+	VLogSize     int64          `json:"vlog_size"`
+	TotalKeys    int            `json:"total_keys"`
+	EntityCounts map[string]int `json:"entity_counts"`
+}
+
+// Stats returns the on-disk sizes reported by Badger together with the total
+// number of keys and the number of records per entity, leaving out
+// soft-deleted ones. Sizes are refreshed
+// by Badger periodically, so they may lag behind recent writes. All counts
+// come from a single read transaction. On a tenant view the counts only cover
+// the tenant's keys, while the sizes are those of the whole database.
+func (s *BadgerService) Stats() (DBStats, error) {
+	if err := s.checkOpen(); err != nil {
+		return DBStats{}, err
+	}
+	
+	stats := DBStats{
+		EntityCounts: make(map[string]int),
+	}
+	stats.LSMSize, stats.VLogSize = s.db.Size()
+	
+	for entity := range entityTypes {
+		stats.EntityCounts[entity] = 0
+	}
+	
+	err := s.view(func(txn *badger.Txn) error {
+		deleted := make(map[string]map[int64]time.Time)
+		for entity := range entityTypes {
+			ids, err := s.softDeletedIDs(txn, entity)
+			if err != nil {
+				return err
+			}
+			deleted[entity] = ids
+		}
+		
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false // Only need keys
+		opts.Prefix = s.scopedKey("")
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		
+		for it.Rewind(); it.Valid(); it.Next() {
+			stats.TotalKeys++
+			
+			key := it.Item().Key()[len(opts.Prefix):]
+			idx := bytes.IndexByte(key, s.sep)
+			if idx == -1 {
+				continue
+			}
+			entity := string(key[:idx])
+			if _, ok := stats.EntityCounts[entity]; ok && !isSoftDeletedKey(deleted[entity], key, key[:idx+1]) {
+				stats.EntityCounts[entity]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return DBStats{}, err
+	}
+	
+	return stats, nil
+}
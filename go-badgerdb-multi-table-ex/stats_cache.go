@@ -0,0 +1,76 @@
+package main
+
+import (
+	"time"
+)
+
+// statsEntities are the entities GetCompanyStats reads; writes to any of them
+// invalidate the cached statistics
+var statsEntities = map[string]bool{
+	"users":     true,
+	"companies": true,
+	"orders":    true,
+}
+
+// computeCompanyStats computes the statistics on a GetCompanyStatsCached
+// miss. Tests replace it to count the scans.
+var computeCompanyStats = (*BadgerService).GetCompanyStats
+
+// companyStatsCache memoizes GetCompanyStats, it is guarded by BadgerService.mu
+type companyStatsCache struct {
+	stats      []CompanyStats
+	computedAt time.Time
+	valid      bool
+
+	// generation is bumped on every invalidation so a computation that
+	// raced with a write is not stored
+	generation uint64
+}
+
+// GetCompanyStatsCached returns GetCompanyStats, reusing the previous result
+// while it is younger than ttl and no user, company or order has been written
+// since. Callers must not modify the returned slice.
+func (s *BadgerService) GetCompanyStatsCached(ttl time.Duration) ([]CompanyStats, error) {
+	s.mu.RLock()
+	cache := s.statsCache
+	s.mu.RUnlock()
+	
+	if cache.valid && time.Since(cache.computedAt) < ttl {
+		return cache.stats, nil
+	}
+	
+	computedAt := time.Now()
+	stats, err := computeCompanyStats(s)
+	if err != nil {
+		return nil, err
+	}
+	
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if s.statsCache.generation == cache.generation {
+		s.statsCache.stats = stats
+		s.statsCache.computedAt = computedAt
+		s.statsCache.valid = true
+	}
+	
+	return stats, nil
+}
+
+// invalidateStatsFor drops the cached statistics if entity feeds into them
+func (s *BadgerService) invalidateStatsFor(entity string) {
+	if !statsEntities[entity] {
+		return
+	}
+	
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resetStatsCache()
+}
+
+// resetStatsCache drops the cached statistics; mu must be held
+func (s *BadgerService) resetStatsCache() {
+	s.statsCache.stats = nil
+	s.statsCache.valid = false
+	s.statsCache.generation++
+}
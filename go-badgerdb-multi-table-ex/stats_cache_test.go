@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetCompanyStatsCached(t *testing.T) {
+	s := newTestService(t)
+	
+	scans := 0
+	compute := computeCompanyStats
+	computeCompanyStats = func(s *BadgerService) ([]CompanyStats, error) {
+		scans++
+		return compute(s)
+	}
+	t.Cleanup(func() { computeCompanyStats = compute })
+	
+	for i := 0; i < 3; i++ {
+		stats, err := s.GetCompanyStatsCached(time.Hour)
+		if err != nil {
+			t.Fatalf("GetCompanyStatsCached: %v", err)
+		}
+		if stats[0].OrderCount != 3 {
+			t.Errorf("call %d: Tech Corp has %d orders, want 3", i, stats[0].OrderCount)
+		}
+	}
+	if scans != 1 {
+		t.Errorf("3 calls within the TTL scanned %d times, want 1", scans)
+	}
+	
+	// A new order invalidates the cache
+	if err := s.CreateOrder(&Order{UserID: 1, ProductID: 3, Quantity: 1, Amount: 19.99, Status: "completed"}); err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	stats, err := s.GetCompanyStatsCached(time.Hour)
+	if err != nil {
+		t.Fatalf("GetCompanyStatsCached: %v", err)
+	}
+	if scans != 2 || stats[0].OrderCount != 4 {
+		t.Errorf("after a write got %d scans and %d Tech Corp orders, want 2 and 4", scans, stats[0].OrderCount)
+	}
+	
+	// Writes to entities the stats do not read keep it
+	if err := s.CreateCategory(&Category{Name: "Garden"}); err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	if _, err := s.GetCompanyStatsCached(time.Hour); err != nil {
+		t.Fatalf("GetCompanyStatsCached: %v", err)
+	}
+	if scans != 2 {
+		t.Errorf("a category write caused a rescan")
+	}
+	
+	// A zero TTL always recomputes
+	if _, err := s.GetCompanyStatsCached(0); err != nil {
+		t.Fatalf("GetCompanyStatsCached: %v", err)
+	}
+	if scans != 3 {
+		t.Errorf("a zero TTL did not rescan")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestStatsCountsRecords(t *testing.T) {
+	s := newTestService(t)
+	
+	for _, name := range []string{"Dana", "Eve"} {
+		if err := s.CreateUser(&User{Name: name, Email: name + "@techcorp.com", CompanyID: 1}); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+	
+	stats, err := s.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	
+	want := map[string]int{"users": 5, "companies": 3, "orders": 4, "products": 3, "categories": 3}
+	total := 0
+	for entity, n := range want {
+		if stats.EntityCounts[entity] != n {
+			t.Errorf("%s: got %d records, want %d", entity, stats.EntityCounts[entity], n)
+		}
+		total += n
+	}
+	// Counters, indexes and audit events are keys too
+	if stats.TotalKeys < total {
+		t.Errorf("got %d keys in total, want at least the %d records", stats.TotalKeys, total)
+	}
+}
+
+func TestStatsSkipsSoftDeletedRecords(t *testing.T) {
+	s := newTestService(t)
+	
+	for _, id := range []int64{1, 3} {
+		if err := s.SoftDelete("orders", id); err != nil {
+			t.Fatalf("SoftDelete: %v", err)
+		}
+	}
+	
+	stats, err := s.Stats()
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.EntityCounts["orders"] != 2 || stats.EntityCounts["users"] != 3 {
+		t.Errorf("got %d orders and %d users, want 2 and 3", stats.EntityCounts["orders"], stats.EntityCounts["users"])
+	}
+}
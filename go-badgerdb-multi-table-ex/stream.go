@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/dgraph-io/ristretto/v2/z"
+)
+
+// StreamEntity calls send with the key and value of every record of entity.
+// Unlike list it scans the prefix with Badger's Stream framework, which
+// splits the key range across several goroutines, so it scales to very large
+// entities. Records are not delivered in key order, but send is never called
+// concurrently. The slices passed to send must not be retained. Records
+// soft-deleted before the stream starts are skipped.
+func (s *BadgerService) StreamEntity(entity string, send func(key, value []byte) error) error {
+	var deleted map[int64]time.Time
+	err := s.view(func(txn *badger.Txn) error {
+		var err error
+		deleted, err = s.softDeletedIDs(txn, entity)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	
+	stream := s.db.NewStream()
+	stream.Prefix = s.entityPrefix(entity)
+	stream.LogPrefix = "BadgerService.StreamEntity"
+	stream.ChooseKey = func(item *badger.Item) bool {
+		return !isSoftDeletedKey(deleted, item.Key(), stream.Prefix)
+	}
+	
+	stream.Send = func(buf *z.Buffer) error {
+		list, err := badger.BufferToKVList(buf)
+		if err != nil {
+			return err
+		}
+		
+		for _, kv := range list.Kv {
+			if err := send(kv.Key, kv.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	
+	return stream.Orchestrate(context.Background())
+}
+
+// StreamUsersJSON writes every user to w as a JSON array, encoding one user
+// at a time so memory stays flat however many users there are. Users are
+// written in key order from a single read transaction, leaving out
+// soft-deleted ones.
+func (s *BadgerService) StreamUsersJSON(w io.Writer) error {
+	return s.view(func(txn *badger.Txn) error {
+		deleted, err := s.softDeletedIDs(txn, "users")
+		if err != nil {
+			return err
+		}
+		
+		opts := s.iteratorOptions()
+		opts.Prefix = s.entityPrefix("users")
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		
+		enc := json.NewEncoder(w)
+		first := true
+		for it.Rewind(); it.Valid(); it.Next() {
+			if isSoftDeletedKey(deleted, it.Item().Key(), opts.Prefix) {
+				continue
+			}
+			
+			var user User
+			err := it.Item().Value(func(val []byte) error {
+				return s.codec.Unmarshal(val, &user)
+			})
+			if err != nil {
+				return err
+			}
+			
+			if !first {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+			
+			if err := enc.Encode(&user); err != nil {
+				return err
+			}
+		}
+		
+		_, err = io.WriteString(w, "]")
+		return err
+	})
+}
+
+// GetProductDescriptionStream writes the description of product id to w. The
+// stored value is decoded in place inside the read transaction, without first
+// copying it out of Badger, and only the description is kept, so a product
+// whose value lives in the value log costs one copy of its description rather
+// than of the whole record. w is written to while the transaction is open.
+func (s *BadgerService) GetProductDescriptionStream(id int64, w io.Writer) error {
+	return s.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(s.recordKey("products", id))
+		if err == badger.ErrKeyNotFound {
+			return notFound("products", id, err)
+		}
+		if err != nil {
+			return err
+		}
+		
+		deleted, err := s.isSoftDeleted(txn, "products", id)
+		if err != nil {
+			return err
+		}
+		if deleted {
+			return notFound("products", id, badger.ErrKeyNotFound)
+		}
+		
+		var product struct {
+			Description string `json:"description"`
+		}
+		err = item.Value(func(val []byte) error {
+			return s.codec.Unmarshal(val, &product)
+		})
+		if err != nil {
+			return err
+		}
+		
+		_, err = io.WriteString(w, product.Description)
+		return err
+	})
+}
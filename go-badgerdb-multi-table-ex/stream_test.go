@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestStreamUsersJSON(t *testing.T) {
+	s := newTestService(t)
+	
+	if err := s.SoftDelete("users", 2); err != nil {
+		t.Fatalf("SoftDelete: %v", err)
+	}
+	
+	var buf bytes.Buffer
+	if err := s.StreamUsersJSON(&buf); err != nil {
+		t.Fatalf("StreamUsersJSON: %v", err)
+	}
+	
+	var got []User
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decoding %q: %v", buf.String(), err)
+	}
+	want, err := s.Users().List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(want) != 2 {
+		t.Fatalf("List returned %d users, want 2 after the soft delete", len(want))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("streamed %+v, want %+v", got, want)
+	}
+}
+
+func TestStreamUsersJSONEmpty(t *testing.T) {
+	s, err := NewInMemoryBadgerService()
+	if err != nil {
+		t.Fatalf("NewInMemoryBadgerService: %v", err)
+	}
+	defer s.Close()
+	
+	var buf bytes.Buffer
+	if err := s.StreamUsersJSON(&buf); err != nil {
+		t.Fatalf("StreamUsersJSON: %v", err)
+	}
+	
+	var got []User
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil || got == nil || len(got) != 0 {
+		t.Errorf("streamed %q, want an empty array", buf.String())
+	}
+}
+
+// streamBenchRecords is the number of users scanned by BenchmarkStreamEntity
+// and BenchmarkPrefetchSize
+const streamBenchRecords = 100_000
+
+// writeBenchUsers stores n users in the database at dir and closes it again,
+// so the users are read from tables rather than the memtable once reopened
+func writeBenchUsers(b *testing.B, dir string, n int64) {
+	b.Helper()
+	
+	s, err := NewBadgerService(dir)
+	if err != nil {
+		b.Fatalf("NewBadgerService: %v", err)
+	}
+	defer s.Close()
+	
+	// Write the users directly, going through CreateUser would take minutes
+	wb := s.db.NewWriteBatch()
+	for i := int64(1); i <= n; i++ {
+		user := User{ID: i, Name: fmt.Sprintf("User %d", i), Email: fmt.Sprintf("user%d@example.com", i), CompanyID: 1}
+		data, err := json.Marshal(user)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := wb.Set(s.recordKey("users", i), data); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func BenchmarkStreamEntity(b *testing.B) {
+	dir := b.TempDir()
+	writeBenchUsers(b, dir, streamBenchRecords)
+	
+	s, err := NewBadgerService(dir)
+	if err != nil {
+		b.Fatalf("NewBadgerService: %v", err)
+	}
+	defer s.Close()
+	
+	b.Run("iterator", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			n := 0
+			err := s.view(func(txn *badger.Txn) error {
+				opts := s.iteratorOptions()
+				opts.Prefix = s.entityPrefix("users")
+				it := txn.NewIterator(opts)
+				defer it.Close()
+				
+				for it.Rewind(); it.Valid(); it.Next() {
+					if err := it.Item().Value(func(val []byte) error { return nil }); err != nil {
+						return err
+					}
+					n++
+				}
+				return nil
+			})
+			if err != nil || n != streamBenchRecords {
+				b.Fatalf("scanned %d users: %v", n, err)
+			}
+		}
+	})
+	b.Run("stream", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			n := 0
+			err := s.StreamEntity("users", func(key, value []byte) error {
+				n++
+				return nil
+			})
+			if err != nil || n != streamBenchRecords {
+				b.Fatalf("streamed %d users: %v", n, err)
+			}
+		}
+	})
+}
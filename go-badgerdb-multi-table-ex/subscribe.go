@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/dgraph-io/badger/v4/pb"
+)
+
+// Subscribe calls fn with every batch of writes to keys starting with prefix,
+// for example "users:" to watch users being created or updated. On a tenant
+// view prefix is relative to the tenant's keys, but the entries keep their
+// full key. Deletes are delivered too, as entries with an empty value.
+// Subscribe blocks until ctx is cancelled, which is a normal return, or until
+// fn returns an error.
+func (s *BadgerService) Subscribe(ctx context.Context, prefix string, fn func(kv *badger.KVList) error) error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	
+	matches := []pb.Match{{Prefix: s.scopedKey(prefix)}}
+	
+	err := s.db.Subscribe(ctx, fn, matches)
+	if err != nil && err == ctx.Err() {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestSubscribeSeesCreatedUser(t *testing.T) {
+	s := newTestService(t)
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	
+	keys := make(chan string, 100)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Subscribe(ctx, "users:", func(kv *badger.KVList) error {
+			for _, e := range kv.Kv {
+				select {
+				case keys <- string(e.Key):
+				default:
+				}
+			}
+			return nil
+		})
+	}()
+	
+	// The subscription registers asynchronously, so keep creating users until
+	// one of them is delivered
+	created := make(map[string]bool)
+	timeout := time.After(5 * time.Second)
+	var got string
+	for got == "" {
+		user := &User{Name: "Subscriber", Email: "sub@techcorp.com", CompanyID: 1}
+		if err := s.CreateUser(user); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		created[string(s.recordKey("users", user.ID))] = true
+		
+		select {
+		case got = <-keys:
+		case <-time.After(20 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("the callback never fired")
+		}
+	}
+	if !created[got] {
+		t.Errorf("callback got key %q, want the key of a created user", got)
+	}
+	
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Subscribe: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Subscribe did not return after cancel")
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ErrInvalidTenant is returned by WithTenant for an ID that would make the
+// tenant's keys collide with other keys of the database
+var ErrInvalidTenant = errors.New("invalid tenant ID")
+
+// reservedPrefixes are the first key segments used by an unscoped service.
+// A tenant with one of these IDs would see, or be seen by, the unscoped data.
+var reservedPrefixes = map[string]bool{
+	"counter": true,
+	"idx":     true,
+	"live":    true,
+	"deleted": true,
+	"audit":   true,
+	"ping":    true,
+}
+
+// WithTenant returns a view of the same database in which every key is
+// prefixed with "<id>:", e.g. "acme:users:1", so tenants sharing a database
+// cannot read each other's records, counters, indexes or audit events.
+//
+// Every view of a tenant shares the tenant's ID counters, so views opened
+// separately still hand out unique IDs. Each view has its own stats cache and
+// validators (copied from s). Backup, Load, the sizes reported by Stats and
+// value log GC still act on the whole database. Closing a view only stops the
+// tenant's counters, which start again on its next create. The database
+// stays open until the service the view came from is closed, which stops the
+// counters of every tenant. Calling WithTenant on a view returns a view of
+// the other tenant, not a nested one.
+func (s *BadgerService) WithTenant(id string) (*BadgerService, error) {
+	if _, isEntity := entityTypes[id]; id == "" || isEntity || reservedPrefixes[id] || strings.IndexByte(id, s.sep) >= 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTenant, id)
+	}
+	
+	s.validatorsMu.RLock()
+	validators := make(map[string]func(json.RawMessage) error, len(s.validators))
+	for entity, fn := range s.validators {
+		validators[entity] = fn
+	}
+	s.validatorsMu.RUnlock()
+	
+	view := &BadgerService{
+		db:            s.db,
+		tenant:        id,
+		readOnly:      s.readOnly,
+		foreignKeys:   s.foreignKeys,
+		countTracking: s.countTracking,
+		sep:           s.sep,
+		closed:        s.closed,
+		prefetchSize:  s.prefetchSize,
+		idGenerator:   s.idGenerator,
+		existence:     s.existence,
+		omitZero:      s.omitZero,
+		tenants:       s.tenants,
+		validators:    validators,
+		nowFn:         s.nowFn,
+		codec:         s.codec,
+		OnOperation:   s.OnOperation,
+	}
+	
+	if err := view.useTenantCounters(); err != nil {
+		return nil, err
+	}
+	if err := view.initLiveCounts(); err != nil {
+		return nil, err
+	}
+	
+	return view, nil
+}
+
+// tenantCounters is the ID counter state of one tenant
+type tenantCounters struct {
+	mu         *sync.RWMutex
+	counters   map[string]int64
+	counterOps map[string]*badger.MergeOperator
+}
+
+// tenantRegistry holds the counter state of every tenant a view was opened
+// for. It is shared by the service and all its views.
+type tenantRegistry struct {
+	mu       sync.Mutex
+	byTenant map[string]*tenantCounters
+}
+
+// useTenantCounters attaches the view to the counter state of its tenant,
+// loading the counters the first time the tenant is used
+func (s *BadgerService) useTenantCounters() error {
+	s.tenants.mu.Lock()
+	defer s.tenants.mu.Unlock()
+	
+	state, ok := s.tenants.byTenant[s.tenant]
+	if !ok {
+		state = &tenantCounters{
+			mu:         new(sync.RWMutex),
+			counters:   make(map[string]int64),
+			counterOps: make(map[string]*badger.MergeOperator),
+		}
+		s.mu, s.counters, s.counterOps = state.mu, state.counters, state.counterOps
+		if err := s.initCounters(); err != nil {
+			return err
+		}
+		s.tenants.byTenant[s.tenant] = state
+	}
+	
+	s.mu, s.counters, s.counterOps = state.mu, state.counters, state.counterOps
+	return nil
+}
+
+// Tenant returns the tenant the service is scoped to, "" if it is not
+func (s *BadgerService) Tenant() string {
+	return s.tenant
+}
+
+// joinKey joins the segments of a key with the key separator of the service
+func (s *BadgerService) joinKey(parts ...string) string {
+	return strings.Join(parts, string(s.sep))
+}
+
+// validKeySeparator reports whether sep can separate key segments without
+// occurring inside one: entity names, IDs and audit timestamps are made of
+// letters, digits, '-' and '_'
+func validKeySeparator(sep byte) bool {
+	return sep != 0 && sep != '-' && sep != '_' &&
+		!('a' <= sep && sep <= 'z') && !('A' <= sep && sep <= 'Z') && !('0' <= sep && sep <= '9')
+}
+
+// scopedKey prefixes key with the tenant of the service, if any
+func (s *BadgerService) scopedKey(key string) []byte {
+	if s.tenant == "" {
+		return []byte(key)
+	}
+	return []byte(s.joinKey(s.tenant, key))
+}
+
+// recordKey returns the key of the record id of entity
+func (s *BadgerService) recordKey(entity string, id int64) []byte {
+	return s.scopedKey(s.joinKey(entity, strconv.FormatInt(id, 10)))
+}
+
+// entityPrefix returns the prefix shared by all records of entity
+func (s *BadgerService) entityPrefix(entity string) []byte {
+	return s.scopedKey(s.joinKey(entity, ""))
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithTenantIsolation(t *testing.T) {
+	s := newTestService(t)
+	
+	acme, err := s.WithTenant("acme")
+	if err != nil {
+		t.Fatalf("WithTenant(acme): %v", err)
+	}
+	globex, err := s.WithTenant("globex")
+	if err != nil {
+		t.Fatalf("WithTenant(globex): %v", err)
+	}
+	
+	if err := acme.CreateUser(&User{Name: "Ann", Email: "ann@acme.test"}); err != nil {
+		t.Fatalf("acme CreateUser: %v", err)
+	}
+	if err := globex.CreateUser(&User{Name: "Gus", Email: "gus@globex.test"}); err != nil {
+		t.Fatalf("globex CreateUser: %v", err)
+	}
+	
+	for _, tc := range []struct {
+		view *BadgerService
+		want string
+	}{
+		{acme, "Ann"},
+		{globex, "Gus"},
+	} {
+		users, err := tc.view.Users().List()
+		if err != nil {
+			t.Fatalf("%s ListUsers: %v", tc.view.Tenant(), err)
+		}
+		if len(users) != 1 || users[0].Name != tc.want {
+			t.Errorf("%s sees %v, want only %s", tc.view.Tenant(), users, tc.want)
+		}
+	}
+	
+	// The unscoped service still sees only the seeded users
+	users, err := s.Users().List()
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	if len(users) != 3 {
+		t.Errorf("unscoped service sees %d users, want 3", len(users))
+	}
+}
+
+func TestWithTenantViewsShareCounters(t *testing.T) {
+	s := newTestService(t)
+	
+	first, err := s.WithTenant("acme")
+	if err != nil {
+		t.Fatalf("WithTenant: %v", err)
+	}
+	second, err := s.WithTenant("acme")
+	if err != nil {
+		t.Fatalf("WithTenant: %v", err)
+	}
+	
+	seen := make(map[int64]bool)
+	create := func(view *BadgerService, name string, inTx bool) {
+		t.Helper()
+		category := &Category{Name: name}
+		if inTx {
+			err = view.WithTransaction(func(tx *Tx) error { return tx.CreateCategory(category) })
+		} else {
+			err = view.CreateCategory(category)
+		}
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		if seen[category.ID] {
+			t.Errorf("%s got ID %d, already handed out", name, category.ID)
+		}
+		seen[category.ID] = true
+	}
+	
+	create(first, "a", true)
+	create(second, "b", true)
+	create(first, "c", false)
+	create(second, "d", true)
+	create(second, "e", false)
+	create(first, "f", true)
+	
+	categories, err := first.Categories().List()
+	if err != nil {
+		t.Fatalf("ListCategories: %v", err)
+	}
+	if len(categories) != 6 {
+		t.Errorf("got %d categories, want 6", len(categories))
+	}
+}
+
+func TestCloseStopsTenantCounters(t *testing.T) {
+	// Fold the counters often enough that an operator left running would
+	// touch the closed database before the test ends
+	interval := counterMergeInterval
+	counterMergeInterval = 5 * time.Millisecond
+	defer func() { counterMergeInterval = interval }()
+	
+	s, err := NewInMemoryBadgerService()
+	if err != nil {
+		t.Fatalf("NewInMemoryBadgerService: %v", err)
+	}
+	var views []*BadgerService
+	for _, id := range []string{"acme", "globex"} {
+		view, err := s.WithTenant(id)
+		if err != nil {
+			t.Fatalf("WithTenant(%s): %v", id, err)
+		}
+		if err := view.CreateCategory(&Category{Name: "Books"}); err != nil {
+			t.Fatalf("%s CreateCategory: %v", id, err)
+		}
+		views = append(views, view)
+	}
+	
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for _, view := range views {
+		if n := len(view.counterOps); n != 0 {
+			t.Errorf("%s has %d counter operators running after Close", view.Tenant(), n)
+		}
+	}
+	
+	// A create after Close starts no new operator
+	if err := views[0].CreateCategory(&Category{Name: "Music"}); !errors.Is(err, ErrServiceClosed) {
+		t.Errorf("CreateCategory after Close = %v, want ErrServiceClosed", err)
+	}
+	if _, err := views[0].getNextID("orders"); !errors.Is(err, ErrServiceClosed) {
+		t.Errorf("getNextID after Close = %v, want ErrServiceClosed", err)
+	}
+	if n := len(views[0].counterOps); n != 0 {
+		t.Errorf("a create after Close started %d counter operators", n)
+	}
+	
+	time.Sleep(20 * counterMergeInterval)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrOpenTimeout is returned by NewBadgerServiceWithTimeout when the database
+// takes too long to open
+var ErrOpenTimeout = errors.New("timed out opening database")
+
+// openService opens the database for NewBadgerServiceWithTimeout. Tests
+// replace it to simulate a slow open.
+var openService = NewBadgerService
+
+// openResult is the outcome of a NewBadgerService call run in the background
+type openResult struct {
+	service *BadgerService
+	err     error
+}
+
+// NewBadgerServiceWithTimeout is NewBadgerService giving up with
+// ErrOpenTimeout after timeout, e.g. while Badger replays the value log of a
+// database that crashed. Badger cannot abort an open, so it keeps running in
+// the background and the service is closed as soon as it is ready, releasing
+// the directory lock.
+func NewBadgerServiceWithTimeout(dbPath string, timeout time.Duration, opts ...Option) (*BadgerService, error) {
+	done := make(chan openResult, 1)
+	go func() {
+		service, err := openService(dbPath, opts...)
+		done <- openResult{service, err}
+	}()
+	
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	
+	select {
+	case res := <-done:
+		return res.service, res.err
+	case <-timer.C:
+		go func() {
+			if res := <-done; res.err == nil {
+				res.service.Close()
+			}
+		}()
+		return nil, fmt.Errorf("%w %s after %s", ErrOpenTimeout, dbPath, timeout)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewBadgerServiceWithTimeout(t *testing.T) {
+	release := make(chan struct{})
+	opened := make(chan *BadgerService, 1)
+	orig := openService
+	openService = func(dbPath string, opts ...Option) (*BadgerService, error) {
+		<-release
+		s, err := NewInMemoryBadgerService(opts...)
+		opened <- s
+		return s, err
+	}
+	t.Cleanup(func() { openService = orig })
+	
+	s, err := NewBadgerServiceWithTimeout("slow", 10*time.Millisecond)
+	if !errors.Is(err, ErrOpenTimeout) || s != nil {
+		t.Fatalf("got %v, %v, want ErrOpenTimeout", s, err)
+	}
+	
+	// The open finishing late must not leak the service
+	close(release)
+	late := <-opened
+	deadline := time.Now().Add(time.Second)
+	for !late.closed.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("the service opened after the timeout was not closed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNewBadgerServiceWithTimeoutOpens(t *testing.T) {
+	s, err := NewBadgerServiceWithTimeout("", time.Minute, WithInMemory(true))
+	if err != nil {
+		t.Fatalf("NewBadgerServiceWithTimeout: %v", err)
+	}
+	defer s.Close()
+	
+	if err := s.Ping(); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+}
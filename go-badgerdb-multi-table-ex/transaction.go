@@ -0,0 +1,116 @@
+package main
+
+import (
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Tx exposes the entity creates on a single Badger transaction so that
+// several records can be committed (or discarded) as one unit
+type Tx struct {
+	s        *BadgerService
+	txn      *badger.Txn
+	counters map[string]int64
+}
+
+// WithTransaction runs fn inside one read-write transaction. If fn returns an
+// error nothing it wrote is persisted, including the ID counters it advanced.
+//
+// The counter lock is held until the transaction finishes, so fn must use the
+// methods on tx and not call back into s. fn runs again if the transaction
+// conflicts with a concurrent write, so it should have no other side effects.
+func (s *BadgerService) WithTransaction(fn func(tx *Tx) error) error {
+	if err := s.checkWritable(); err != nil {
+		return err
+	}
+	
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	tx := &Tx{
+		s:        s,
+		counters: make(map[string]int64),
+	}
+	
+	err := s.updateWithRetry(func(txn *badger.Txn) error {
+		tx.txn = txn
+		clear(tx.counters)
+		return fn(tx)
+	}, defaultMaxRetries)
+	if err != nil {
+		return err
+	}
+	
+	// Only publish the allocated IDs once the transaction has committed
+	for entity, counter := range tx.counters {
+		s.counters[entity] = counter
+	}
+	s.resetStatsCache()
+	
+	return nil
+}
+
+// nextID allocates an ID by writing the counter inside the transaction
+func (tx *Tx) nextID(entity string) (int64, error) {
+	counter, ok := tx.counters[entity]
+	if !ok {
+		counter = tx.s.counters[entity]
+	}
+	counter++
+	
+	// The counter lock is held, so writing a new base value cannot lose a
+	// concurrent increment
+	if err := setCounterBase(tx.txn, tx.s.counterKey(entity), counter); err != nil {
+		return 0, err
+	}
+	
+	tx.counters[entity] = counter
+	return counter, nil
+}
+
+func (tx *Tx) CreateUser(user *User) error {
+	id, err := tx.nextID("users")
+	if err != nil {
+		return err
+	}
+	user.ID = id
+	user.CreatedAt = tx.s.nowFn()
+	return tx.s.setRecord(tx.txn, "users", user.ID, user)
+}
+
+func (tx *Tx) CreateCompany(company *Company) error {
+	id, err := tx.nextID("companies")
+	if err != nil {
+		return err
+	}
+	company.ID = id
+	company.CreatedAt = tx.s.nowFn()
+	return tx.s.setRecord(tx.txn, "companies", company.ID, company)
+}
+
+func (tx *Tx) CreateOrder(order *Order) error {
+	id, err := tx.nextID("orders")
+	if err != nil {
+		return err
+	}
+	order.ID = id
+	order.CreatedAt = tx.s.nowFn()
+	return tx.s.setRecord(tx.txn, "orders", order.ID, order)
+}
+
+func (tx *Tx) CreateProduct(product *Product) error {
+	id, err := tx.nextID("products")
+	if err != nil {
+		return err
+	}
+	product.ID = id
+	return tx.s.setRecord(tx.txn, "products", product.ID, product)
+}
+
+func (tx *Tx) CreateCategory(category *Category) error {
+	id, err := tx.nextID("categories")
+	if err != nil {
+		return err
+	}
+	category.ID = id
+	return tx.s.setRecord(tx.txn, "categories", category.ID, category)
+}
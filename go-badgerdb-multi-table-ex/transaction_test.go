@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithTransactionRollsBack(t *testing.T) {
+	s := newTestService(t)
+	
+	errAbort := errors.New("abort")
+	var company Company
+	err := s.WithTransaction(func(tx *Tx) error {
+		company = Company{Name: "Rolled Back Inc"}
+		if err := tx.CreateCompany(&company); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithTransaction: got %v, want %v", err, errAbort)
+	}
+	
+	if _, err := s.Companies().Get(company.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("company %d: got %v, want ErrNotFound", company.ID, err)
+	}
+	companies, err := s.Companies().List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(companies) != 3 {
+		t.Errorf("got %d companies, want the 3 seeded ones", len(companies))
+	}
+	
+	// The ID allocated inside the transaction is handed out again
+	next := &Company{Name: "Next Inc"}
+	if err := s.CreateCompany(next); err != nil {
+		t.Fatalf("CreateCompany: %v", err)
+	}
+	if next.ID != company.ID {
+		t.Errorf("got ID %d after the rollback, want %d", next.ID, company.ID)
+	}
+}
+
+func TestWithTransactionCommits(t *testing.T) {
+	s := newTestService(t)
+	
+	company := &Company{Name: "Committed Inc"}
+	user := &User{Name: "Dana", Email: "dana@committed.com"}
+	err := s.WithTransaction(func(tx *Tx) error {
+		if err := tx.CreateCompany(company); err != nil {
+			return err
+		}
+		user.CompanyID = company.ID
+		return tx.CreateUser(user)
+	})
+	if err != nil {
+		t.Fatalf("WithTransaction: %v", err)
+	}
+	
+	got, err := s.Users().Get(user.ID)
+	if err != nil {
+		t.Fatalf("Get user %d: %v", user.ID, err)
+	}
+	if got.CompanyID != company.ID {
+		t.Errorf("user company %d, want %d", got.CompanyID, company.ID)
+	}
+	if _, err := s.Companies().Get(company.ID); err != nil {
+		t.Errorf("Get company %d: %v", company.ID, err)
+	}
+}
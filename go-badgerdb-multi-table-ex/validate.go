@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+)
+
+// SetValidator registers v to check every record of entity before it is
+// written. A record v rejects is not persisted and v's error is returned
+// unchanged. Passing a nil v removes the validator.
+func (s *BadgerService) SetValidator(entity string, v func(json.RawMessage) error) {
+	s.validatorsMu.Lock()
+	defer s.validatorsMu.Unlock()
+	
+	if v == nil {
+		delete(s.validators, entity)
+		return
+	}
+	s.validators[entity] = v
+}
+
+// validate runs the validator registered for entity, if any
+func (s *BadgerService) validate(entity string, data json.RawMessage) error {
+	s.validatorsMu.RLock()
+	v := s.validators[entity]
+	s.validatorsMu.RUnlock()
+	
+	if v == nil {
+		return nil
+	}
+	return v(data)
+}
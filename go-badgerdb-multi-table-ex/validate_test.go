@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestValidatorRejectsBadRecords(t *testing.T) {
+	s := newTestService(t)
+	
+	errNoEmail := errors.New("email is required")
+	s.SetValidator("users", func(data json.RawMessage) error {
+		var user User
+		if err := json.Unmarshal(data, &user); err != nil {
+			return err
+		}
+		if user.Email == "" {
+			return errNoEmail
+		}
+		return nil
+	})
+	
+	bad := &User{Name: "No Email", CompanyID: 1}
+	if err := s.CreateUser(bad); err != errNoEmail {
+		t.Fatalf("CreateUser: got %v, want the validator's error", err)
+	}
+	if _, err := s.Users().Get(bad.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("rejected user %d: got %v, want ErrNotFound", bad.ID, err)
+	}
+	
+	// Updates are validated too
+	alice, err := s.Users().Get(1)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	alice.Email = ""
+	if err := s.Users().Update(alice); err != errNoEmail {
+		t.Fatalf("Update: got %v, want the validator's error", err)
+	}
+	if alice, err = s.Users().Get(1); err != nil || alice.Email == "" {
+		t.Errorf("after the rejected update got %+v, %v", alice, err)
+	}
+	
+	good := &User{Name: "Dana", Email: "dana@techcorp.com", CompanyID: 1}
+	if err := s.CreateUser(good); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	
+	s.SetValidator("users", nil)
+	if err := s.CreateUser(&User{Name: "No Email Again", CompanyID: 1}); err != nil {
+		t.Errorf("CreateUser without a validator: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Verify checks the integrity of the stored data and describes every problem
+// it finds: users whose company is missing, orders whose user or products are
+// missing, products whose category is missing and ID counters that are
+// corrupt or below the highest stored ID. Like get and list it treats
+// soft-deleted records as gone, so they are not checked and references to
+// them are reported, while the counters are checked against every stored ID.
+// All checks run in one read transaction. An empty result means the data is
+// consistent.
+func (s *BadgerService) Verify() ([]string, error) {
+	var problems []string
+	
+	err := s.view(func(txn *badger.Txn) error {
+		ids := make(map[string]map[int64]bool)
+		for _, entity := range []string{"users", "companies", "products", "categories"} {
+			set, err := s.liveIDs(txn, entity)
+			if err != nil {
+				return err
+			}
+			ids[entity] = set
+		}
+		
+		err := s.eachRecord(txn, "users", func(record interface{}) {
+			user := record.(*User)
+			if !ids["companies"][user.CompanyID] {
+				problems = append(problems, fmt.Sprintf("users %d: company %d does not exist", user.ID, user.CompanyID))
+			}
+		})
+		if err != nil {
+			return err
+		}
+		
+		err = s.eachRecord(txn, "orders", func(record interface{}) {
+			order := record.(*Order)
+			if !ids["users"][order.UserID] {
+				problems = append(problems, fmt.Sprintf("orders %d: user %d does not exist", order.ID, order.UserID))
+			}
+			for _, item := range order.Lines() {
+				if !ids["products"][item.ProductID] {
+					problems = append(problems, fmt.Sprintf("orders %d: product %d does not exist", order.ID, item.ProductID))
+				}
+			}
+		})
+		if err != nil {
+			return err
+		}
+		
+		err = s.eachRecord(txn, "products", func(record interface{}) {
+			product := record.(*Product)
+			if !ids["categories"][product.CategoryID] {
+				problems = append(problems, fmt.Sprintf("products %d: category %d does not exist", product.ID, product.CategoryID))
+			}
+		})
+		if err != nil {
+			return err
+		}
+		
+		for _, entity := range []string{"users", "companies", "orders", "products", "categories"} {
+			counter, _, err := readCounter(txn, s.counterKey(entity))
+			if errors.Is(err, ErrCorruptCounter) {
+				problems = append(problems, err.Error())
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			maxID, err := maxStoredID(txn, s.entityPrefix(entity))
+			if err != nil {
+				return err
+			}
+			if counter < maxID {
+				problems = append(problems, fmt.Sprintf("counter of %s is %d, below the highest ID %d", entity, counter, maxID))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	return problems, nil
+}
+
+// eachRecord decodes every record of entity that is not soft-deleted and
+// passes it to fn as a pointer to its struct type
+func (s *BadgerService) eachRecord(txn *badger.Txn, entity string, fn func(record interface{})) error {
+	deleted, err := s.softDeletedIDs(txn, entity)
+	if err != nil {
+		return err
+	}
+	
+	opts := s.iteratorOptions()
+	opts.Prefix = s.entityPrefix(entity)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	
+	for it.Rewind(); it.Valid(); it.Next() {
+		if isSoftDeletedKey(deleted, it.Item().Key(), opts.Prefix) {
+			continue
+		}
+		
+		record := entityTypes[entity]()
+		err := it.Item().Value(func(val []byte) error {
+			return s.codec.Unmarshal(val, record)
+		})
+		if err != nil {
+			return fmt.Errorf("decoding %s: %w", it.Item().Key(), err)
+		}
+		fn(record)
+	}
+	
+	return nil
+}
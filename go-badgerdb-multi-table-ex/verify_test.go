@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestVerifyConsistentData(t *testing.T) {
+	s := newTestService(t)
+	
+	problems, err := s.Verify()
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("Verify reported %q for the seeded data", problems)
+	}
+}
+
+func TestVerifyReportsInconsistencies(t *testing.T) {
+	s := newTestService(t)
+	
+	// Foreign keys are not checked by default, so dangling references can be
+	// stored through the service
+	if err := s.CreateUser(&User{Name: "Dana White", Email: "dana@nowhere.com", CompanyID: 42}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if err := s.CreateOrder(&Order{UserID: 99, ProductID: 77, Quantity: 1, Amount: 5, Status: "pending"}); err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	if err := s.CreateProduct(&Product{Name: "Mystery Box", Price: 5, CategoryID: 55}); err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+	err := s.db.Update(func(txn *badger.Txn) error {
+		return setCounterBase(txn, s.counterKey("companies"), 1)
+	})
+	if err != nil {
+		t.Fatalf("lowering the counter: %v", err)
+	}
+	
+	problems, err := s.Verify()
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	
+	want := []string{
+		"users 4: company 42 does not exist",
+		"orders 5: user 99 does not exist",
+		"orders 5: product 77 does not exist",
+		"products 4: category 55 does not exist",
+		"counter of companies is 1, below the highest ID 3",
+	}
+	reported := make(map[string]bool)
+	for _, p := range problems {
+		reported[p] = true
+	}
+	for _, w := range want {
+		if !reported[w] {
+			t.Errorf("Verify did not report %q", w)
+		}
+	}
+	if len(problems) != len(want) {
+		t.Errorf("Verify reported %q, want %q", problems, want)
+	}
+}
+
+func TestVerifySkipsSoftDeletedRecords(t *testing.T) {
+	s := newTestService(t)
+	
+	// A hidden user may point anywhere, but a live order of a hidden product
+	// points to a record that is gone
+	dana := &User{Name: "Dana White", Email: "dana@nowhere.com", CompanyID: 42}
+	if err := s.CreateUser(dana); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	for _, d := range []struct {
+		entity string
+		id     int64
+	}{
+		{"users", dana.ID},
+		{"products", 3},
+	} {
+		if err := s.SoftDelete(d.entity, d.id); err != nil {
+			t.Fatalf("SoftDelete(%s, %d): %v", d.entity, d.id, err)
+		}
+	}
+	
+	problems, err := s.Verify()
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if want := "orders 2: product 3 does not exist"; len(problems) != 1 || problems[0] != want {
+		t.Errorf("Verify reported %q, want only %q", problems, want)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMethodsAfterClose(t *testing.T) {
+	s := newTestService(t)
+	user := createTestUser(t, s, "Alice", "alice@example.com", 30)
+	
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	
+	if _, err := s.ListUsers(); !errors.Is(err, ErrServiceClosed) {
+		t.Errorf("ListUsers: got %v, want ErrServiceClosed", err)
+	}
+	if _, err := s.GetUserByID(user.ID); !errors.Is(err, ErrServiceClosed) {
+		t.Errorf("GetUserByID: got %v, want ErrServiceClosed", err)
+	}
+	if err := s.CreateUser(&UserBadger{Name: "Bob"}); !errors.Is(err, ErrServiceClosed) {
+		t.Errorf("CreateUser: got %v, want ErrServiceClosed", err)
+	}
+	if err := s.DeleteUser(user.ID); !errors.Is(err, ErrServiceClosed) {
+		t.Errorf("DeleteUser: got %v, want ErrServiceClosed", err)
+	}
+}
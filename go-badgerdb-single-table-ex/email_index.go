@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ErrDuplicateEmail is returned when a write would give two users the same email
+var ErrDuplicateEmail = errors.New("email already in use")
+
+// maxConflictRetries bounds how often a write is retried after Badger reports
+// a conflict with a concurrent transaction
+const maxConflictRetries = 5
+
+// emailKey is the unique index entry mapping an email to the ID of its user
+func emailKey(email string) []byte {
+	return []byte("idx:users:email:" + email)
+}
+
+// claimEmail points the email index at user as part of txn, releasing the
+// entry for previousEmail. It fails with ErrDuplicateEmail if the email
+// belongs to another user. Users without an email are not indexed.
+func claimEmail(txn *badger.Txn, user *UserBadger, previousEmail string) error {
+	if user.Email != "" {
+		item, err := txn.Get(emailKey(user.Email))
+		if err != nil && err != badger.ErrKeyNotFound {
+			return err
+		}
+		if err == nil {
+			var ownerID int64
+			err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &ownerID)
+			})
+			if err != nil {
+				return err
+			}
+			if ownerID != user.ID {
+				return ErrDuplicateEmail
+			}
+		}
+	}
+	
+	if previousEmail != "" && previousEmail != user.Email {
+		if err := txn.Delete(emailKey(previousEmail)); err != nil {
+			return err
+		}
+	}
+	
+	if user.Email == "" {
+		return nil
+	}
+	return txn.Set(emailKey(user.Email), []byte(strconv.FormatInt(user.ID, 10)))
+}
+
+// emailIndexBuiltKey marks a database whose email index covers every user.
+// Databases written before the index existed lack it.
+var emailIndexBuiltKey = []byte("meta:idx:users:email")
+
+// buildEmailIndex indexes the emails of users stored before the email index
+// existed, then marks the index as built so later opens skip the scan. When
+// such users share an email the one with the lowest ID keeps it; the others
+// stay unindexed until their email is changed.
+func (s *BadgerService) buildEmailIndex() error {
+	return s.update(func(txn *badger.Txn) error {
+		if _, err := txn.Get(emailIndexBuiltKey); err != badger.ErrKeyNotFound {
+			return err
+		}
+		
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		
+		prefix := []byte("users:")
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			var user UserBadger
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &user)
+			})
+			if err != nil {
+				return err
+			}
+			if user.Email == "" {
+				continue
+			}
+			
+			if _, err := txn.Get(emailKey(user.Email)); err != badger.ErrKeyNotFound {
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if err := txn.Set(emailKey(user.Email), []byte(strconv.FormatInt(user.ID, 10))); err != nil {
+				return err
+			}
+		}
+		
+		return txn.Set(emailIndexBuiltKey, []byte("1"))
+	})
+}
+
+// readUser decodes the user stored under key within txn
+func readUser(txn *badger.Txn, key []byte) (*UserBadger, error) {
+	item, err := txn.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	
+	var user UserBadger
+	err = item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	return &user, nil
+}
+
+// update runs fn in a read-write transaction, retrying when it conflicts with
+// a concurrent one. Because the email check reads the index inside the same
+// transaction, two creates racing for one email conflict here and the retry
+// then reports ErrDuplicateEmail.
+func (s *BadgerService) update(fn func(txn *badger.Txn) error) error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	
+	var err error
+	for attempt := 0; attempt < maxConflictRetries; attempt++ {
+		err = s.db.Update(fn)
+		if err != badger.ErrConflict {
+			return err
+		}
+	}
+	return err
+}
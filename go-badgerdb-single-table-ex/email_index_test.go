@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestCreateUserDuplicateEmailConcurrent(t *testing.T) {
+	s := newTestService(t)
+	
+	const goroutines = 10
+	errs := make([]error, goroutines)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = s.CreateUser(&UserBadger{Name: fmt.Sprintf("User %d", i), Email: "same@example.com"})
+		}(i)
+	}
+	wg.Wait()
+	
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrDuplicateEmail):
+			t.Errorf("CreateUser: got %v, want nil or ErrDuplicateEmail", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d creates succeeded, want exactly 1", succeeded)
+	}
+	
+	users, err := s.ListUsers()
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	if len(users) != 1 {
+		t.Errorf("stored %d users, want 1", len(users))
+	}
+}
+
+func TestEmailIndexBackfilledOnOpen(t *testing.T) {
+	dir := t.TempDir()
+	
+	// Users written before the email index existed
+	db, err := badger.Open(badger.DefaultOptions(dir).WithLogger(nil))
+	if err != nil {
+		t.Fatalf("badger.Open: %v", err)
+	}
+	err = db.Update(func(txn *badger.Txn) error {
+		for id, email := range map[int64]string{1: "old@example.com", 2: "other@example.com"} {
+			data, err := json.Marshal(&UserBadger{ID: id, Name: "Legacy", Email: email, Version: 1})
+			if err != nil {
+				return err
+			}
+			if err := txn.Set([]byte(fmt.Sprintf("users:%d", id)), data); err != nil {
+				return err
+			}
+		}
+		return txn.Set([]byte("counter:users"), []byte("2"))
+	})
+	if err != nil {
+		t.Fatalf("writing legacy users: %v", err)
+	}
+	db.Close()
+	
+	s, err := NewBadgerService(dir)
+	if err != nil {
+		t.Fatalf("NewBadgerService: %v", err)
+	}
+	defer s.Close()
+	
+	err = s.CreateUser(&UserBadger{Name: "New", Email: "old@example.com"})
+	if !errors.Is(err, ErrDuplicateEmail) {
+		t.Errorf("CreateUser with a legacy email: got %v, want ErrDuplicateEmail", err)
+	}
+	
+	// A legacy user can still change its own record
+	legacy, err := s.GetUserByID(2)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	legacy.Age = 50
+	if err := s.UpdateUser(legacy); err != nil {
+		t.Errorf("UpdateUser of a legacy user: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"github.com/dgraph-io/badger/v4"
+	"github.com/dgraph-io/badger/v4/options"
+)
+
+// Option customizes how NewBadgerService opens the database
+type Option func(*serviceOptions)
+
+type serviceOptions struct {
+	logger      badger.Logger
+	inMemory    bool
+	compression *options.CompressionType
+}
+
+// WithLogger routes Badger's own diagnostics to logger. By default they are
+// discarded for cleaner output.
+func WithLogger(logger badger.Logger) Option {
+	return func(o *serviceOptions) {
+		o.logger = logger
+	}
+}
+
+// WithInMemory keeps the whole store in memory. The database path is ignored
+// and nothing is written to disk, which is handy for tests.
+func WithInMemory(inMemory bool) Option {
+	return func(o *serviceOptions) {
+		o.inMemory = inMemory
+	}
+}
+
+// WithCompression selects the block compression Badger applies to its
+// tables: options.None, options.Snappy or options.ZSTD. Compression trades
+// CPU on every read and write for a smaller store, and pays off for large,
+// repetitive JSON records. Values big enough to live in the value log are
+// not compressed. Without this option Badger's default (Snappy) is used.
+func WithCompression(compression options.CompressionType) Option {
+	return func(o *serviceOptions) {
+		o.compression = &compression
+	}
+}
+
+// badgerOptions builds the Badger options for dbPath
+func (o serviceOptions) badgerOptions(dbPath string) badger.Options {
+	opts := badger.DefaultOptions(dbPath)
+	opts.Logger = o.logger
+	
+	if o.compression != nil {
+		opts = opts.WithCompression(*o.compression)
+	}
+	
+	if o.inMemory {
+		opts = opts.WithDir("").WithValueDir("").WithInMemory(true)
+	}
+	
+	return opts
+}
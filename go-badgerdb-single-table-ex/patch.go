@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ErrUnknownField is returned by PatchUser for a field that does not exist
+// or cannot be patched
+var ErrUnknownField = errors.New("unknown field")
+
+// patchableUserFields are the JSON names of the fields PatchUser may change.
+// The ID, version and timestamps are maintained by the service.
+var patchableUserFields = map[string]bool{
+	"name":  true,
+	"email": true,
+	"age":   true,
+}
+
+// PatchUser changes only the given fields of a user, keyed by JSON name, e.g.
+// {"age": 31}, and returns the updated user. The user is read, merged and
+// written back in one transaction, refreshing UpdatedAt and the Version.
+func (s *BadgerService) PatchUser(id int64, patch map[string]interface{}) (*UserBadger, error) {
+	for name := range patch {
+		if !patchableUserFields[name] {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownField, name)
+		}
+	}
+	
+	var patched UserBadger
+	err := s.update(func(txn *badger.Txn) error {
+		key := fmt.Sprintf("users:%d", id)
+		
+		existing, err := readUser(txn, []byte(key))
+		if err != nil {
+			return readUserError(id, err)
+		}
+		
+		// Merge through a map so only the patched fields change
+		data, err := json.Marshal(existing)
+		if err != nil {
+			return err
+		}
+		var merged map[string]interface{}
+		if err := json.Unmarshal(data, &merged); err != nil {
+			return err
+		}
+		for name, value := range patch {
+			merged[name] = value
+		}
+		
+		if data, err = json.Marshal(merged); err != nil {
+			return err
+		}
+		user := UserBadger{}
+		if err := json.Unmarshal(data, &user); err != nil {
+			return fmt.Errorf("failed to patch user %d: %w", id, err)
+		}
+		user.UpdatedAt = s.nowFn()
+		user.Version = existing.Version + 1
+		
+		if err := claimEmail(txn, &user, existing.Email); err != nil {
+			return err
+		}
+		
+		if data, err = json.Marshal(&user); err != nil {
+			return fmt.Errorf("failed to marshal user: %w", err)
+		}
+		
+		patched = user
+		return txn.Set([]byte(key), data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	return &patched, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPatchUserAge(t *testing.T) {
+	s := newTestService(t)
+	user := createTestUser(t, s, "Alice", "alice@example.com", 30)
+	
+	later := testClock.Add(time.Hour)
+	s.SetClock(func() time.Time { return later })
+	
+	patched, err := s.PatchUser(user.ID, map[string]interface{}{"age": 31})
+	if err != nil {
+		t.Fatalf("PatchUser: %v", err)
+	}
+	
+	stored, err := s.GetUserByID(user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	for _, got := range []*UserBadger{patched, stored} {
+		if got.Age != 31 {
+			t.Errorf("Age = %d, want 31", got.Age)
+		}
+		if got.Name != "Alice" || got.Email != "alice@example.com" {
+			t.Errorf("name and email changed to %q, %q", got.Name, got.Email)
+		}
+		if !got.CreatedAt.Equal(testClock) {
+			t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, testClock)
+		}
+		if !got.UpdatedAt.Equal(later) {
+			t.Errorf("UpdatedAt = %v, want %v", got.UpdatedAt, later)
+		}
+		if got.Version != 2 {
+			t.Errorf("Version = %d, want 2", got.Version)
+		}
+	}
+}
+
+func TestPatchUserRejectsUnknownFields(t *testing.T) {
+	s := newTestService(t)
+	user := createTestUser(t, s, "Alice", "alice@example.com", 30)
+	
+	for _, field := range []string{"nickname", "id", "version"} {
+		_, err := s.PatchUser(user.ID, map[string]interface{}{field: 1})
+		if !errors.Is(err, ErrUnknownField) {
+			t.Errorf("patching %q: got %v, want ErrUnknownField", field, err)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ConflictPolicy decides what SyncBunToBadger does with a user whose ID
+// already exists in Badger
+type ConflictPolicy int
+
+const (
+	// OverwriteExisting replaces the Badger user with the PostgreSQL row
+	OverwriteExisting ConflictPolicy = iota
+	// SkipExisting keeps the Badger user untouched
+	SkipExisting
+)
+
+// bunIDKey holds the ID of the PostgreSQL row that Badger user id was last
+// synced to
+func bunIDKey(id int64) []byte {
+	return []byte(fmt.Sprintf("sync:bun:users:%d", id))
+}
+
+// SyncBadgerToBun copies every Badger user into PostgreSQL and returns how
+// many were written. Rows are matched by email: an existing row keeps its ID
+// and gets the name, age and timestamps of the Badger user, a new row gets an
+// ID assigned by PostgreSQL, so Badger and PostgreSQL IDs may differ. The
+// PostgreSQL ID of every synced user is recorded, see BunUserID. Users
+// without an email cannot be matched and are skipped.
+func SyncBadgerToBun(ctx context.Context, b *BadgerService, bunService *BunService) (int, error) {
+	users, err := b.ListUsers()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list badger users: %w", err)
+	}
+	
+	rows := make([]*User, 0, len(users))
+	badgerIDs := make([]int64, 0, len(users))
+	for _, user := range users {
+		if user.Email == "" {
+			continue
+		}
+		badgerIDs = append(badgerIDs, user.ID)
+		rows = append(rows, &User{
+			Name:      user.Name,
+			Email:     user.Email,
+			Age:       user.Age,
+			CreatedAt: user.CreatedAt,
+			UpdatedAt: user.UpdatedAt,
+		})
+	}
+	
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	
+	_, err = bunService.db.NewInsert().
+		Model(&rows).
+		On("CONFLICT (email) DO UPDATE").
+		Set("name = EXCLUDED.name").
+		Set("age = EXCLUDED.age").
+		Set("created_at = EXCLUDED.created_at").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("id").
+		Exec(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to upsert users: %w", err)
+	}
+	
+	if err := b.checkOpen(); err != nil {
+		return len(rows), err
+	}
+	wb := b.db.NewWriteBatch()
+	defer wb.Cancel()
+	for i, row := range rows {
+		if err := wb.Set(bunIDKey(badgerIDs[i]), []byte(strconv.FormatInt(row.ID, 10))); err != nil {
+			return len(rows), fmt.Errorf("failed to record the id mapping: %w", err)
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		return len(rows), fmt.Errorf("failed to record the id mapping: %w", err)
+	}
+	
+	return len(rows), nil
+}
+
+// BunUserID returns the ID of the PostgreSQL row that SyncBadgerToBun last
+// wrote Badger user id to, or ErrNotFound if the user was never synced
+func (s *BadgerService) BunUserID(id int64) (int64, error) {
+	var bunID int64
+	err := s.view(func(txn *badger.Txn) error {
+		item, err := txn.Get(bunIDKey(id))
+		if err != nil {
+			return err
+		}
+		
+		return item.Value(func(val []byte) error {
+			bunID, err = strconv.ParseInt(string(val), 10, 64)
+			return err
+		})
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return 0, fmt.Errorf("user %d was never synced: %w (%w)", id, ErrNotFound, err)
+	}
+	
+	return bunID, err
+}
+
+// UserSource lists the users SyncBunToBadger imports. *BunService reads them
+// from PostgreSQL; tests can pass a fixed dataset instead.
+type UserSource interface {
+	ListUsers(ctx context.Context) ([]*User, error)
+}
+
+// SyncBunToBadger imports every PostgreSQL user into Badger, keeping their
+// IDs, and returns how many users were written. policy decides whether users
+// already present in Badger are overwritten or skipped. The Badger counter is
+// raised to the highest imported ID so new users do not collide with them.
+func SyncBunToBadger(ctx context.Context, source UserSource, b *BadgerService, policy ConflictPolicy) (int, error) {
+	rows, err := source.ListUsers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	
+	imported := 0
+	var maxID int64
+	// Raise the counter even if the import stops half way
+	defer func() {
+		b.ensureCounterAtLeast(maxID)
+	}()
+	
+	for _, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return imported, err
+		}
+		
+		user := &UserBadger{
+			ID:        row.ID,
+			Name:      row.Name,
+			Email:     row.Email,
+			Age:       row.Age,
+			CreatedAt: row.CreatedAt,
+			UpdatedAt: row.UpdatedAt,
+		}
+		
+		written := false
+		err := b.update(func(txn *badger.Txn) error {
+			written = false
+			key := fmt.Sprintf("users:%d", user.ID)
+			
+			user.Version = 1
+			previousEmail := ""
+			existing, err := readUser(txn, []byte(key))
+			if err != nil && err != badger.ErrKeyNotFound {
+				return fmt.Errorf("failed to read user: %w", err)
+			}
+			if err == nil {
+				if policy == SkipExisting {
+					return nil
+				}
+				previousEmail = existing.Email
+				user.Version = existing.Version + 1
+			}
+			
+			if err := claimEmail(txn, user, previousEmail); err != nil {
+				return err
+			}
+			
+			data, err := json.Marshal(user)
+			if err != nil {
+				return fmt.Errorf("failed to marshal user: %w", err)
+			}
+			
+			written = true
+			return txn.Set([]byte(key), data)
+		})
+		if err != nil {
+			return imported, fmt.Errorf("failed to import user %d: %w", user.ID, err)
+		}
+		
+		if written {
+			imported++
+		}
+		if user.ID > maxID {
+			maxID = user.ID
+		}
+	}
+	
+	return imported, nil
+}
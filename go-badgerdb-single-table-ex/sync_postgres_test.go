@@ -0,0 +1,92 @@
+//go:build postgres
+
+// These tests need a PostgreSQL database, found through $BUN_DSN or the
+// default DSN of DefaultBunConfig. Run them with: go test -tags postgres
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// newTestBunService connects to the test database, closed when the test ends
+func newTestBunService(t *testing.T) *BunService {
+	t.Helper()
+	
+	cfg := DefaultBunConfig()
+	cfg.Debug = false
+	bunService, err := NewBunService(cfg)
+	if err != nil {
+		t.Fatalf("NewBunService: %v", err)
+	}
+	t.Cleanup(func() { bunService.Close() })
+	return bunService
+}
+
+func TestSyncBadgerToBun(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	bunService := newTestBunService(t)
+	
+	// Tag the emails so rows left by earlier runs do not count
+	run := time.Now().UnixNano()
+	var emails []string
+	for i := 0; i < 3; i++ {
+		email := fmt.Sprintf("sync%d_%d@example.com", run, i)
+		emails = append(emails, email)
+		createTestUser(t, s, fmt.Sprintf("User %d", i), email, 20+i)
+	}
+	createTestUser(t, s, "No Email", "", 40)
+	t.Cleanup(func() {
+		bunService.db.NewDelete().Model((*User)(nil)).Where("email IN (?)", bun.In(emails)).Exec(ctx)
+	})
+	
+	n, err := SyncBadgerToBun(ctx, s, bunService)
+	if err != nil {
+		t.Fatalf("SyncBadgerToBun: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("synced %d users, want 3", n)
+	}
+	
+	// Syncing again updates the same rows
+	if _, err := SyncBadgerToBun(ctx, s, bunService); err != nil {
+		t.Fatalf("second SyncBadgerToBun: %v", err)
+	}
+	
+	var rows []User
+	err = bunService.db.NewSelect().Model(&rows).Where("email IN (?)", bun.In(emails)).Scan(ctx)
+	if err != nil {
+		t.Fatalf("selecting the synced rows: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("PostgreSQL holds %d synced rows, want 3", len(rows))
+	}
+	
+	users, err := s.ListUsers()
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	rowIDs := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		rowIDs[row.Email] = row.ID
+	}
+	for _, user := range users {
+		bunID, err := s.BunUserID(user.ID)
+		if user.Email == "" {
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("BunUserID of the user without email: got %d, %v, want ErrNotFound", bunID, err)
+			}
+			continue
+		}
+		if err != nil || bunID != rowIDs[user.Email] {
+			t.Errorf("BunUserID(%d) = %d, %v, want %d", user.ID, bunID, err, rowIDs[user.Email])
+		}
+	}
+}
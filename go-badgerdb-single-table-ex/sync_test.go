@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// stubUsers is a UserSource serving a fixed dataset
+type stubUsers []*User
+
+func (s stubUsers) ListUsers(ctx context.Context) ([]*User, error) {
+	return s, nil
+}
+
+func TestSyncBunToBadger(t *testing.T) {
+	ctx := context.Background()
+	
+	rows := stubUsers{
+		{ID: 3, Name: "Alice", Email: "alice@example.com", Age: 30, CreatedAt: testClock, UpdatedAt: testClock},
+		{ID: 7, Name: "Bob", Email: "bob@example.com", Age: 40, CreatedAt: testClock, UpdatedAt: testClock},
+	}
+	
+	for _, tt := range []struct {
+		policy   ConflictPolicy
+		imported int
+		name     string
+	}{
+		{OverwriteExisting, 2, "Alice"},
+		{SkipExisting, 1, "Alicia"},
+	} {
+		s := newTestService(t)
+		existing := &UserBadger{ID: 3, Name: "Alicia", Email: "alicia@example.com", Age: 29}
+		if err := s.UpsertUser(existing); err != nil {
+			t.Fatalf("UpsertUser: %v", err)
+		}
+		
+		n, err := SyncBunToBadger(ctx, rows, s, tt.policy)
+		if err != nil {
+			t.Fatalf("policy %d: SyncBunToBadger: %v", tt.policy, err)
+		}
+		if n != tt.imported {
+			t.Errorf("policy %d: imported %d users, want %d", tt.policy, n, tt.imported)
+		}
+		
+		user, err := s.GetUserByID(3)
+		if err != nil || user.Name != tt.name {
+			t.Errorf("policy %d: user 3 is %+v, %v, want %s", tt.policy, user, err, tt.name)
+		}
+		bob, err := s.GetUserByID(7)
+		if err != nil || bob.Email != "bob@example.com" || !bob.CreatedAt.Equal(testClock) {
+			t.Errorf("policy %d: user 7 is %+v, %v, want Bob", tt.policy, bob, err)
+		}
+		
+		// New users are numbered after the imported ones
+		next := createTestUser(t, s, "Carol", "carol@example.com", 50)
+		if next.ID != 8 {
+			t.Errorf("policy %d: next user got ID %d, want 8", tt.policy, next.ID)
+		}
+	}
+}